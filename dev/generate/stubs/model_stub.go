@@ -21,10 +21,10 @@ import (
 
 func (m *{{ModelName}}) Save(fields ...string) (err error) {
 	o := orm.NewOrm()
-	if m.ID > 0 {
+	if orm.HasPk(m) {
 		_, err = o.Update(m, fields...)
 	} else {
-		m.ID, err = o.Insert(m)
+		_, err = o.Insert(m)
 	}
 	return
 }
@@ -36,6 +36,8 @@ func printHelp(errs ...string) {
 
     syncdb     - auto create tables
     sqlall     - print sql of create tables
+    migrate    - run versioned migrations, up|down|status|redo
+    diff       - print sql to migrate database to registered models
     help       - print this help
 `
 
@@ -260,9 +262,103 @@ func (d *commandSQLAll) Run() error {
 	return nil
 }
 
+// versioned migration commander interface implement.
+type commandMigrate struct {
+	al     *alias
+	action string
+	steps  int
+}
+
+// parse orm command line arguments.
+func (d *commandMigrate) Parse(args []string) {
+	var name string
+
+	d.action = "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		d.action = args[0]
+		args = args[1:]
+	}
+
+	flagSet := flag.NewFlagSet("orm command: migrate", flag.ExitOnError)
+	flagSet.StringVar(&name, "db", "default", "DataBase alias name")
+	flagSet.IntVar(&d.steps, "n", 0, "number of migrations to run, up default all, down default 1")
+	flagSet.Parse(args)
+
+	d.al = getDbAlias(name)
+}
+
+// run orm line command.
+func (d *commandMigrate) Run() error {
+	var err error
+	switch d.action {
+	case "up":
+		var n int
+		n, err = MigrateUp(d.al.Name, d.steps)
+		fmt.Printf("applied %d migration(s)\n", n)
+	case "down":
+		var n int
+		n, err = MigrateDown(d.al.Name, d.steps)
+		fmt.Printf("rolled back %d migration(s)\n", n)
+	case "redo":
+		err = MigrateRedo(d.al.Name, d.steps)
+	case "status":
+		var status []MigrationStatus
+		status, err = MigrateStatus(d.al.Name)
+		for _, s := range status {
+			applied := "pending"
+			if s.Applied {
+				applied = s.At.Format(formatDateTime)
+			}
+			fmt.Printf("%-20s %-40s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		printHelp(fmt.Sprintf("unknown migrate action %s", d.action))
+	}
+
+	if err != nil {
+		fmt.Printf("    %s\n", err.Error())
+	}
+	return err
+}
+
+// schema diff commander interface implement.
+type commandDiff struct {
+	al *alias
+}
+
+// parse orm command line arguments.
+func (d *commandDiff) Parse(args []string) {
+	var name string
+
+	flagSet := flag.NewFlagSet("orm command: diff", flag.ExitOnError)
+	flagSet.StringVar(&name, "db", "default", "DataBase alias name")
+	flagSet.Parse(args)
+
+	d.al = getDbAlias(name)
+}
+
+// run orm line command.
+func (d *commandDiff) Run() error {
+	diffs, err := getDbDiffSQL(d.al)
+	if err != nil {
+		fmt.Printf("    %s\n", err.Error())
+		return err
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("-- database is up to date")
+		return nil
+	}
+	fmt.Println(strings.Join(diffs, "\n\n"))
+
+	return nil
+}
+
 func init() {
 	commands["syncdb"] = new(commandSyncDb)
 	commands["sqlall"] = new(commandSQLAll)
+	commands["migrate"] = new(commandMigrate)
+	commands["diff"] = new(commandDiff)
 }
 
 // RunSyncdb run syncdb command line.
@@ -281,3 +377,11 @@ func RunSyncdb(name string, force bool, verbose bool) error {
 	cmd.rtOnError = true
 	return cmd.Run()
 }
+
+// GetDbDiffSQL return sql statements needed to migrate database of alias name
+// to the registered models, e.g. as content of a migration.
+func GetDbDiffSQL(name string) ([]string, error) {
+	BootStrap()
+
+	return getDbDiffSQL(getDbAlias(name))
+}
@@ -17,6 +17,8 @@ package orm
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -46,6 +48,7 @@ func getColumnTyp(al *alias, fi *fieldInfo) (col string) {
 	T := al.DbBaser.DbTypes()
 	fieldType := fi.fieldType
 	fieldSize := fi.size
+	isUUID := fi.uuid != ""
 
 checkColumn:
 	switch fieldType {
@@ -58,7 +61,11 @@ checkColumn:
 			col = fmt.Sprintf(T["string"], fieldSize)
 		}
 	case TypeCharField:
-		col = fmt.Sprintf(T["string-char"], fieldSize)
+		if isUUID && T["uuid"] != "" {
+			col = T["uuid"]
+		} else {
+			col = fmt.Sprintf(T["string-char"], fieldSize)
+		}
 	case TypeTextField:
 		col = T["string-text"]
 	case TypeTimeField:
@@ -111,6 +118,7 @@ checkColumn:
 	case RelForeignKey, RelOneToOne:
 		fieldType = fi.relModelInfo.fields.pk.fieldType
 		fieldSize = fi.relModelInfo.fields.pk.size
+		isUUID = fi.relModelInfo.fields.pk.uuid != ""
 		goto checkColumn
 	}
 
@@ -140,137 +148,159 @@ func getDbCreateSQL(al *alias) (sqls []string, tableIndexes map[string][]dbIndex
 		os.Exit(2)
 	}
 
-	Q := al.DbBaser.TableQuote()
-	T := al.DbBaser.DbTypes()
-	sep := fmt.Sprintf("%s, %s", Q, Q)
-
 	tableIndexes = make(map[string][]dbIndex)
 
 	for _, mi := range modelCache.allOrdered() {
-		sql := fmt.Sprintf("-- %s\n", strings.Repeat("-", 50))
-		sql += fmt.Sprintf("--  Table Structure for `%s`\n", mi.fullName)
-		sql += fmt.Sprintf("-- %s\n", strings.Repeat("-", 50))
+		sql, indexes := getTableCreateSQL(al, mi)
+		sqls = append(sqls, sql)
+		if len(indexes) > 0 {
+			tableIndexes[mi.table] = append(tableIndexes[mi.table], indexes...)
+		}
+	}
 
-		sql += fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s%s%s (\n", Q, mi.table, Q)
+	return
+}
 
-		columns := make([]string, 0, len(mi.fields.fieldsDB))
+// create table creation string and indexes of model.
+func getTableCreateSQL(al *alias, mi *modelInfo) (string, []dbIndex) {
+	Q := al.DbBaser.TableQuote()
+	T := al.DbBaser.DbTypes()
+	sep := fmt.Sprintf("%s, %s", Q, Q)
 
-		sqlIndexes := [][]string{}
+	var indexes []dbIndex
 
-		for _, fi := range mi.fields.fieldsDB {
+	sql := fmt.Sprintf("-- %s\n", strings.Repeat("-", 50))
+	sql += fmt.Sprintf("--  Table Structure for `%s`\n", mi.fullName)
+	sql += fmt.Sprintf("-- %s\n", strings.Repeat("-", 50))
 
-			column := fmt.Sprintf("    %s%s%s ", Q, fi.column, Q)
-			col := getColumnTyp(al, fi)
+	sql += fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s%s%s (\n", Q, mi.table, Q)
 
-			if fi.auto {
-				switch al.Driver {
-				case DRSqlite, DRPostgres:
-					column += T["auto"]
-				default:
-					column += col + " " + T["auto"]
-				}
-			} else if fi.pk {
-				column += col + " " + T["pk"]
-			} else {
-				column += col
+	columns := make([]string, 0, len(mi.fields.fieldsDB))
 
-				if !fi.null {
-					column += " " + "NOT NULL"
-				}
+	sqlIndexes := [][]string{}
 
-				//if fi.initial.String() != "" {
-				//	column += " DEFAULT " + fi.initial.String()
-				//}
+	for _, fi := range mi.fields.fieldsDB {
 
-				// Append attribute DEFAULT
-				column += getColumnDefault(fi)
+		column := fmt.Sprintf("    %s%s%s ", Q, fi.column, Q)
+		col := getColumnTyp(al, fi)
 
-				if fi.unique {
-					column += " " + "UNIQUE"
-				}
+		if fi.auto {
+			switch al.Driver {
+			case DRSqlite, DRPostgres:
+				column += T["auto"]
+			default:
+				column += col + " " + T["auto"]
+			}
+		} else if fi.pk && len(mi.fields.pks) == 1 {
+			column += col + " " + T["pk"]
+		} else if fi.pk {
+			// composite pk is added as table constraint
+			column += col + " " + "NOT NULL"
+		} else {
+			column += col
 
-				if fi.index {
-					sqlIndexes = append(sqlIndexes, []string{fi.column})
-				}
+			if !fi.null {
+				column += " " + "NOT NULL"
 			}
 
-			if strings.Contains(column, "%COL%") {
-				column = strings.Replace(column, "%COL%", fi.column, -1)
+			//if fi.initial.String() != "" {
+			//	column += " DEFAULT " + fi.initial.String()
+			//}
+
+			// Append attribute DEFAULT
+			column += getColumnDefault(fi)
+
+			if fi.unique {
+				column += " " + "UNIQUE"
 			}
 
-			if fi.description != "" {
-				column += " " + fmt.Sprintf("COMMENT '%s'", fi.description)
+			if fi.index {
+				sqlIndexes = append(sqlIndexes, []string{fi.column})
 			}
+		}
 
-			columns = append(columns, column)
+		if strings.Contains(column, "%COL%") {
+			column = strings.Replace(column, "%COL%", fi.column, -1)
 		}
 
-		if mi.model != nil {
-			allnames := getTableUnique(mi.addrField)
-			if !mi.manual && len(mi.uniques) > 0 {
-				allnames = append(allnames, mi.uniques)
-			}
-			for _, names := range allnames {
-				cols := make([]string, 0, len(names))
-				for _, name := range names {
-					if fi, ok := mi.fields.GetByAny(name); ok && fi.dbcol {
-						cols = append(cols, fi.column)
-					} else {
-						panic(fmt.Errorf("cannot found column `%s` when parse UNIQUE in `%s.TableUnique`", name, mi.fullName))
-					}
-				}
-				column := fmt.Sprintf("    UNIQUE (%s%s%s)", Q, strings.Join(cols, sep), Q)
-				columns = append(columns, column)
-			}
+		if fi.description != "" {
+			column += " " + fmt.Sprintf("COMMENT '%s'", fi.description)
 		}
 
-		sql += strings.Join(columns, ",\n")
-		sql += "\n)"
+		columns = append(columns, column)
+	}
 
-		if al.Driver == DRMySQL {
-			var engine string
-			if mi.model != nil {
-				engine = getTableEngine(mi.addrField)
-			}
-			if engine == "" {
-				engine = al.Engine
+	if len(mi.fields.pks) > 1 {
+		cols := make([]string, 0, len(mi.fields.pks))
+		for _, fi := range mi.fields.pks {
+			cols = append(cols, fi.column)
+		}
+		columns = append(columns, fmt.Sprintf("    PRIMARY KEY (%s%s%s)", Q, strings.Join(cols, sep), Q))
+	}
+
+	if mi.model != nil {
+		allnames := getTableUnique(mi.addrField)
+		if !mi.manual && len(mi.uniques) > 0 {
+			allnames = append(allnames, mi.uniques)
+		}
+		for _, names := range allnames {
+			cols := make([]string, 0, len(names))
+			for _, name := range names {
+				if fi, ok := mi.fields.GetByAny(name); ok && fi.dbcol {
+					cols = append(cols, fi.column)
+				} else {
+					panic(fmt.Errorf("cannot found column `%s` when parse UNIQUE in `%s.TableUnique`", name, mi.fullName))
+				}
 			}
-			sql += " ENGINE=" + engine
+			column := fmt.Sprintf("    UNIQUE (%s%s%s)", Q, strings.Join(cols, sep), Q)
+			columns = append(columns, column)
 		}
+	}
 
-		sql += ";"
-		sqls = append(sqls, sql)
+	sql += strings.Join(columns, ",\n")
+	sql += "\n)"
 
+	if al.Driver == DRMySQL {
+		var engine string
 		if mi.model != nil {
-			for _, names := range getTableIndex(mi.addrField) {
-				cols := make([]string, 0, len(names))
-				for _, name := range names {
-					if fi, ok := mi.fields.GetByAny(name); ok && fi.dbcol {
-						cols = append(cols, fi.column)
-					} else {
-						panic(fmt.Errorf("cannot found column `%s` when parse INDEX in `%s.TableIndex`", name, mi.fullName))
-					}
+			engine = getTableEngine(mi.addrField)
+		}
+		if engine == "" {
+			engine = al.Engine
+		}
+		sql += " ENGINE=" + engine
+	}
+
+	sql += ";"
+
+	if mi.model != nil {
+		for _, names := range getTableIndex(mi.addrField) {
+			cols := make([]string, 0, len(names))
+			for _, name := range names {
+				if fi, ok := mi.fields.GetByAny(name); ok && fi.dbcol {
+					cols = append(cols, fi.column)
+				} else {
+					panic(fmt.Errorf("cannot found column `%s` when parse INDEX in `%s.TableIndex`", name, mi.fullName))
 				}
-				sqlIndexes = append(sqlIndexes, cols)
 			}
+			sqlIndexes = append(sqlIndexes, cols)
 		}
+	}
 
-		for _, names := range sqlIndexes {
-			name := mi.table + "_" + strings.Join(names, "_")
-			cols := strings.Join(names, sep)
-			sql := fmt.Sprintf("CREATE INDEX %s%s%s ON %s%s%s (%s%s%s);", Q, name, Q, Q, mi.table, Q, Q, cols, Q)
-
-			index := dbIndex{}
-			index.Table = mi.table
-			index.Name = name
-			index.SQL = sql
+	for _, names := range sqlIndexes {
+		name := mi.table + "_" + strings.Join(names, "_")
+		cols := strings.Join(names, sep)
+		sql := fmt.Sprintf("CREATE INDEX %s%s%s ON %s%s%s (%s%s%s);", Q, name, Q, Q, mi.table, Q, Q, cols, Q)
 
-			tableIndexes[mi.table] = append(tableIndexes[mi.table], index)
-		}
+		index := dbIndex{}
+		index.Table = mi.table
+		index.Name = name
+		index.SQL = sql
 
+		indexes = append(indexes, index)
 	}
 
-	return
+	return sql, indexes
 }
 
 // Get string value for the attribute "DEFAULT" for the CREATE, ALTER commands
@@ -318,3 +348,182 @@ func getColumnDefault(fi *fieldInfo) string {
 
 	return v
 }
+
+// create sql statements to migrate live database to registered models.
+func getDbDiffSQL(al *alias) (diffs []string, err error) {
+	db := al.DB
+	Q := al.DbBaser.TableQuote()
+
+	tables, err := al.DbBaser.GetTables(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mi := range modelCache.allOrdered() {
+		sql, indexes := getTableCreateSQL(al, mi)
+		if !tables[mi.table] {
+			diffs = append(diffs, sql)
+			for _, idx := range indexes {
+				diffs = append(diffs, idx.SQL)
+			}
+			continue
+		}
+
+		columns, err := al.DbBaser.GetColumnsInfo(db, mi.table)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fi := range mi.fields.fieldsDB {
+			unique := fi.unique && !fi.pk
+			if col, ok := columns[fi.column]; !ok {
+				diffs = append(diffs, getColumnAddQuery(al, fi)+";")
+			} else {
+				diffs = append(diffs, getColumnAlterQueries(al, fi, col)...)
+				unique = unique && !col.Unique
+			}
+
+			if unique {
+				diffs = append(diffs, getColumnUniqueQuery(al, fi))
+			}
+		}
+
+		var removed []string
+		for name := range columns {
+			if fi := mi.fields.GetByColumn(name); fi == nil || !fi.dbcol {
+				removed = append(removed, name)
+			}
+		}
+		sort.Strings(removed)
+		for _, name := range removed {
+			diffs = append(diffs, fmt.Sprintf("ALTER TABLE %s%s%s DROP COLUMN %s%s%s;", Q, mi.table, Q, Q, name, Q))
+		}
+
+		for _, idx := range indexes {
+			if !al.DbBaser.IndexExists(db, idx.Table, idx.Name) {
+				diffs = append(diffs, idx.SQL)
+			}
+		}
+	}
+
+	return diffs, nil
+}
+
+// create unique index sql string of field column.
+func getColumnUniqueQuery(al *alias, fi *fieldInfo) string {
+	Q := al.DbBaser.TableQuote()
+	name := fi.mi.table + "_" + fi.column + "_uniq"
+	return fmt.Sprintf("CREATE UNIQUE INDEX %s%s%s ON %s%s%s (%s%s%s);", Q, name, Q, Q, fi.mi.table, Q, Q, fi.column, Q)
+}
+
+// create alter sql strings when live column differs from field definition.
+func getColumnAlterQueries(al *alias, fi *fieldInfo, col dbColumn) (queries []string) {
+	if fi.pk || fi.auto {
+		return
+	}
+
+	typ := strings.Replace(getColumnTyp(al, fi), "%COL%", fi.column, -1)
+	def, hasDef := getColumnDefaultValue(fi)
+
+	typChanged := typ != "" && normalizeColumnType(al.Driver, typ) != normalizeColumnType(al.Driver, col.Type)
+	nullChanged := fi.null != col.Null
+	defChanged := hasDef != col.HasDefault || hasDef && normalizeColumnDefault(def) != normalizeColumnDefault(col.Default)
+
+	if !typChanged && !nullChanged && !defChanged {
+		return
+	}
+
+	Q := al.DbBaser.TableQuote()
+	table := fmt.Sprintf("%s%s%s", Q, fi.mi.table, Q)
+	column := fmt.Sprintf("%s%s%s", Q, fi.column, Q)
+
+	switch al.Driver {
+	case DRMySQL, DRTiDB:
+		if !fi.null {
+			typ += " NOT NULL"
+		}
+		queries = append(queries, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s%s;", table, column, typ, strings.TrimRight(getColumnDefault(fi), " ")))
+	case DRPostgres:
+		if typChanged {
+			// postgres column type do not carry check constraint
+			if i := strings.Index(typ, " CHECK"); i != -1 {
+				typ = typ[:i]
+			}
+			queries = append(queries, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, column, typ))
+		}
+		if nullChanged {
+			if fi.null {
+				queries = append(queries, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, column))
+			} else {
+				queries = append(queries, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, column))
+			}
+		}
+		if defChanged {
+			if hasDef {
+				queries = append(queries, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET%s;", table, column, strings.TrimRight(getColumnDefault(fi), " ")))
+			} else {
+				queries = append(queries, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, column))
+			}
+		}
+	default:
+		queries = append(queries, fmt.Sprintf("-- `%s` cannot alter column `%s` of table `%s`, expected `%s`, rebuild the table manually",
+			al.DriverName, fi.column, fi.mi.table, typ))
+	}
+
+	return
+}
+
+// get field default value as stored by database, false if it has no default.
+func getColumnDefaultValue(fi *fieldInfo) (string, bool) {
+	v := strings.TrimSpace(getColumnDefault(fi))
+	if v == "" {
+		return "", false
+	}
+	return strings.TrimPrefix(v, "DEFAULT "), true
+}
+
+var (
+	reColumnTypeWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+	reColumnDefCast   = regexp.MustCompile(`::[a-z ]+(\(\d+\))?$`)
+)
+
+// normalize column type string so field and database types are comparable.
+func normalizeColumnType(dr DriverType, typ string) string {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if i := strings.Index(typ, " check"); i != -1 {
+		typ = typ[:i]
+	}
+	typ = strings.Replace(typ, ", ", ",", -1)
+
+	switch dr {
+	case DRMySQL, DRTiDB:
+		switch {
+		case typ == "bool" || typ == "boolean":
+			return "tinyint(1)"
+		case typ == "tinyint(1)":
+			return typ
+		}
+		typ = reColumnTypeWidth.ReplaceAllString(typ, "$1")
+		typ = strings.Replace(typ, "integer", "int", 1)
+		typ = strings.Replace(typ, "numeric", "decimal", 1)
+		typ = strings.Replace(typ, "double precision", "double", 1)
+	case DRPostgres:
+		typ = strings.Replace(typ, "boolean", "bool", 1)
+	}
+	return typ
+}
+
+// normalize column default so field and database defaults are comparable.
+func normalizeColumnDefault(def string) string {
+	def = reColumnDefCast.ReplaceAllString(strings.TrimSpace(def), "")
+	if len(def) >= 2 && def[0] == '\'' && def[len(def)-1] == '\'' {
+		def = def[1 : len(def)-1]
+	}
+	switch strings.ToLower(def) {
+	case "false":
+		def = "0"
+	case "true":
+		def = "1"
+	}
+	return def
+}
@@ -39,9 +39,14 @@ var (
 )
 
 // CodeGenerator help to generate code format based on data from database table
+//
+// Deprecated: concurrent calls get the same code, use Sequence instead.
 func CodeGenerator(t CodeType, prefix string, tableName string, field string) (code string) {
 	var lastCode string
-	NewOrm().Raw("SELECT " + field + " FROM " + tableName + " where " + field + " like '" + prefix + "%%' ORDER BY id DESC LIMIT 1").QueryRow(&lastCode)
+	o := NewOrm()
+	Q := o.(*orm).alias.DbBaser.TableQuote()
+	query := fmt.Sprintf("SELECT %s%s%s FROM %s%s%s WHERE %s%s%s LIKE ? ORDER BY id DESC LIMIT 1", Q, field, Q, Q, tableName, Q, Q, field, Q)
+	o.Raw(query, prefix+"%").QueryRow(&lastCode)
 
 	if t == CodeRoman {
 		code = generateRomanCode(prefix, lastCode)
@@ -16,11 +16,14 @@ package orm
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"time"
+
+	"github.com/raryanda/go/utility"
 )
 
 const (
@@ -36,12 +39,12 @@ var (
 
 var (
 	operators = map[string]bool{
-		"exact":     true,
-		"iexact":    true,
-		"contains":  true,
-		"icontains": true,
-		// "regex":       true,
-		// "iregex":      true,
+		"exact":       true,
+		"iexact":      true,
+		"contains":    true,
+		"icontains":   true,
+		"regex":       true,
+		"iregex":      true,
 		"gt":          true,
 		"gte":         true,
 		"lt":          true,
@@ -55,12 +58,12 @@ var (
 		"iendswith":   true,
 		"in":          true,
 		"between":     true,
-		// "year":        true,
-		// "month":       true,
-		// "day":         true,
-		// "week_day":    true,
-		"isnull": true,
-		// "search":      true,
+		"year":        true,
+		"month":       true,
+		"day":         true,
+		"week_day":    true,
+		"isnull":      true,
+		"search":      true,
 	}
 )
 
@@ -119,12 +122,32 @@ func (d *dbBase) collectValues(mi *modelInfo, ind reflect.Value, cols []string,
 func (d *dbBase) collectFieldValue(mi *modelInfo, fi *fieldInfo, ind reflect.Value, insert bool, tz *time.Location) (interface{}, error) {
 	var value interface{}
 	if fi.pk {
-		_, value, _ = getExistPk(mi, ind)
+		var exist bool
+		value, exist = getPkValue(fi, ind)
+		if insert && !exist && fi.uuid != "" {
+			uuid := newUUID(fi.uuid)
+			ind.FieldByIndex(fi.fieldIndex).SetString(uuid)
+			value = uuid
+		}
 	} else {
 		field := ind.FieldByIndex(fi.fieldIndex)
 		if fi.isFielder {
 			f := field.Addr().Interface().(Fielder)
 			value = f.RawValue()
+		} else if fi.jsonValue {
+			switch field.Kind() {
+			case reflect.Ptr, reflect.Map, reflect.Slice:
+				if field.IsNil() {
+					break
+				}
+				fallthrough
+			default:
+				b, err := json.Marshal(field.Interface())
+				if err != nil {
+					return nil, fmt.Errorf("field `%s` cannot be encoded to json, %s", fi.fullName, err)
+				}
+				value = string(b)
+			}
 		} else {
 			switch fi.fieldType {
 			case TypeBooleanField:
@@ -324,13 +347,12 @@ func (d *dbBase) Read(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.Lo
 			return err
 		}
 	} else {
-		// default use pk value as where condtion.
-		pkColumn, pkValue, ok := getExistPk(mi, ind)
+		// default use pk values as where condtion.
+		var ok bool
+		whereCols, args, ok = getExistPks(mi, ind)
 		if !ok {
 			return ErrMissPK
 		}
-		whereCols = []string{pkColumn}
-		args = append(args, pkValue)
 	}
 
 	Q := d.ins.TableQuote()
@@ -347,7 +369,12 @@ func (d *dbBase) Read(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.Lo
 		forUpdate = "FOR UPDATE"
 	}
 
-	query := fmt.Sprintf("SELECT %s%s%s FROM %s%s%s WHERE %s%s%s = ? %s", Q, sels, Q, Q, mi.table, Q, Q, wheres, Q, forUpdate)
+	trashed := ""
+	if fi := mi.fields.softDelete; fi != nil {
+		trashed = fmt.Sprintf("AND %s%s%s IS NULL ", Q, fi.column, Q)
+	}
+
+	query := fmt.Sprintf("SELECT %s%s%s FROM %s%s%s WHERE %s%s%s = ? %s%s", Q, sels, Q, Q, mi.table, Q, Q, wheres, Q, trashed, forUpdate)
 
 	refs := make([]interface{}, colsNum)
 	for i := range refs {
@@ -599,9 +626,114 @@ func (d *dbBase) InsertOrUpdate(q dbQuerier, mi *modelInfo, ind reflect.Value, a
 	return id, err
 }
 
+// multi-insert sql with given slice struct reflect.Value,
+// rows conflict on conflicts columns update the cols with inserted values.
+// cols default to all inserted columns except the conflicts, pk and auto_now_add columns.
+func (d *dbBase) InsertOrUpdateMulti(q dbQuerier, mi *modelInfo, sind reflect.Value, bulk int, conflicts []string, cols []string, tz *time.Location) (int64, error) {
+	if bulk < 1 {
+		bulk = 1
+	}
+
+	conflicts, err := getColumns(mi, conflicts)
+	if err != nil {
+		return 0, err
+	}
+	if cols, err = getColumns(mi, cols); err != nil {
+		return 0, err
+	}
+
+	var (
+		cnt    int64
+		nums   int
+		values []interface{}
+		names  []string
+		upsert string
+	)
+
+	Q := d.ins.TableQuote()
+	exec := func(values []interface{}) (int64, error) {
+		marks := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+		qmarks := strings.Repeat(marks+"), (", len(values)/len(names)-1) + marks
+		columns := strings.Join(names, fmt.Sprintf("%s, %s", Q, Q))
+		query := fmt.Sprintf("INSERT INTO %s%s%s (%s%s%s) VALUES (%s) %s", Q, mi.table, Q, Q, columns, Q, qmarks, upsert)
+
+		d.ins.ReplaceMarks(&query)
+
+		res, err := q.Exec(query, values...)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	length := sind.Len()
+	for i := 1; i <= length; i++ {
+		ind := reflect.Indirect(sind.Index(i - 1))
+
+		if i == 1 {
+			vus, _, err := d.collectValues(mi, ind, mi.fields.dbcols, false, true, &names, tz)
+			if err != nil {
+				return cnt, err
+			}
+			if len(cols) == 0 {
+				for _, name := range names {
+					fi := mi.fields.GetByColumn(name)
+					if !fi.pk && !fi.autoNowAdd && !utility.Contains(conflicts, name) {
+						cols = append(cols, name)
+					}
+				}
+			}
+			if upsert, err = d.ins.UpsertSQL(conflicts, cols); err != nil {
+				return cnt, err
+			}
+			values = make([]interface{}, bulk*len(vus))
+			nums += copy(values, vus)
+		} else {
+			vus, _, err := d.collectValues(mi, ind, mi.fields.dbcols, false, true, nil, tz)
+			if err != nil {
+				return cnt, err
+			}
+
+			if len(vus) != len(names) {
+				return cnt, ErrArgs
+			}
+
+			nums += copy(values[nums:], vus)
+		}
+
+		if i%bulk == 0 || length == i {
+			num, err := exec(values[:nums])
+			if err != nil {
+				return cnt, err
+			}
+			cnt += num
+			nums = 0
+		}
+	}
+	return cnt, nil
+}
+
+// get the clause of insert sql, which update cols with inserted values when rows conflict on conflicts columns.
+func (d *dbBase) UpsertSQL(conflicts []string, cols []string) (string, error) {
+	if len(conflicts) == 0 {
+		return "", fmt.Errorf("upsert must have conflict columns")
+	}
+	Q := d.ins.TableQuote()
+
+	action := "NOTHING"
+	if len(cols) > 0 {
+		sets := make([]string, len(cols))
+		for i, col := range cols {
+			sets[i] = fmt.Sprintf("%s%s%s = EXCLUDED.%s%s%s", Q, col, Q, Q, col, Q)
+		}
+		action = "UPDATE SET " + strings.Join(sets, ", ")
+	}
+	return fmt.Sprintf("ON CONFLICT (%s%s%s) DO %s", Q, strings.Join(conflicts, fmt.Sprintf("%s, %s", Q, Q)), Q, action), nil
+}
+
 // execute update sql dbQuerier with given struct reflect.Value.
 func (d *dbBase) Update(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.Location, cols []string) (int64, error) {
-	pkName, pkValue, ok := getExistPk(mi, ind)
+	pkNames, pkValues, ok := getExistPks(mi, ind)
 	if !ok {
 		return 0, ErrMissPK
 	}
@@ -616,27 +748,74 @@ func (d *dbBase) Update(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.
 		setNames = make([]string, 0, len(cols))
 	}
 
+	// version column is increased by database, not by given value.
+	vfi := mi.fields.version
+	if vfi != nil {
+		tmp := make([]string, 0, len(cols))
+		for _, col := range cols {
+			if fi, ok := mi.fields.GetByAny(col); !ok || fi != vfi {
+				tmp = append(tmp, col)
+			}
+		}
+		cols = tmp
+	}
+
 	setValues, _, err := d.collectValues(mi, ind, cols, true, false, &setNames, tz)
 	if err != nil {
 		return 0, err
 	}
 
-	setValues = append(setValues, pkValue)
+	setValues = append(setValues, pkValues...)
 
 	Q := d.ins.TableQuote()
 
-	sep := fmt.Sprintf("%s = ?, %s", Q, Q)
-	setColumns := strings.Join(setNames, sep)
+	sets := make([]string, 0, len(setNames)+1)
+	for _, name := range setNames {
+		sets = append(sets, fmt.Sprintf("%s%s%s = ?", Q, name, Q))
+	}
+	// version is always increased, cols of version field only just bump it
+	if vfi != nil {
+		sets = append(sets, fmt.Sprintf("%s%s%s = %s%s%s + 1", Q, vfi.column, Q, Q, vfi.column, Q))
+	}
+	if len(sets) == 0 {
+		return 0, fmt.Errorf("<Ormer.Update> no column to update for model `%s`", mi.fullName)
+	}
+
+	sep := fmt.Sprintf("%s = ? AND %s", Q, Q)
+	wheres := strings.Join(pkNames, sep)
 
-	query := fmt.Sprintf("UPDATE %s%s%s SET %s%s%s = ? WHERE %s%s%s = ?", Q, mi.table, Q, Q, setColumns, Q, Q, pkName, Q)
+	query := fmt.Sprintf("UPDATE %s%s%s SET %s WHERE %s%s%s = ?", Q, mi.table, Q, strings.Join(sets, ", "), Q, wheres, Q)
+
+	if vfi != nil {
+		query += fmt.Sprintf(" AND %s%s%s = ?", Q, vfi.column, Q)
+		setValues = append(setValues, ind.FieldByIndex(vfi.fieldIndex).Interface())
+	}
 
 	d.ins.ReplaceMarks(&query)
 
 	res, err := q.Exec(query, setValues...)
-	if err == nil {
+	if err != nil {
+		return 0, err
+	}
+	if vfi == nil {
 		return res.RowsAffected()
 	}
-	return 0, err
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if num == 0 {
+		return 0, ErrStaleObject
+	}
+
+	field := ind.FieldByIndex(vfi.fieldIndex)
+	if vfi.fieldType&IsPositiveIntegerField > 0 {
+		field.SetUint(field.Uint() + 1)
+	} else {
+		field.SetInt(field.Int() + 1)
+	}
+	return num, nil
 }
 
 // execute delete sql dbQuerier with given struct reflect.Value.
@@ -653,13 +832,12 @@ func (d *dbBase) Delete(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.
 			return 0, err
 		}
 	} else {
-		// default use pk value as where condtion.
-		pkColumn, pkValue, ok := getExistPk(mi, ind)
+		// default use pk values as where condtion.
+		var ok bool
+		whereCols, args, ok = getExistPks(mi, ind)
 		if !ok {
 			return 0, ErrMissPK
 		}
-		whereCols = []string{pkColumn}
-		args = append(args, pkValue)
 	}
 
 	Q := d.ins.TableQuote()
@@ -694,6 +872,72 @@ func (d *dbBase) Delete(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.
 	return 0, err
 }
 
+// execute soft delete sql dbQuerier with given struct reflect.Value.
+// set the soft delete field to current time instead of delete row.
+func (d *dbBase) SoftDelete(q dbQuerier, mi *modelInfo, ind reflect.Value, tz *time.Location, cols []string) (int64, error) {
+	sfi := mi.fields.softDelete
+	if sfi == nil {
+		return d.ins.Delete(q, mi, ind, tz, cols)
+	}
+
+	var whereCols []string
+	var args []interface{}
+	// if specify cols length > 0, then use it for where condition.
+	if len(cols) > 0 {
+		var err error
+		whereCols = make([]string, 0, len(cols))
+		args, _, err = d.collectValues(mi, ind, cols, false, false, &whereCols, tz)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		// default use pk values as where condtion.
+		var ok bool
+		whereCols, args, ok = getExistPks(mi, ind)
+		if !ok {
+			return 0, ErrMissPK
+		}
+	}
+
+	tnow := time.Now()
+	if sfi.fieldType == TypeDateField {
+		tnow = time.Date(tnow.Year(), tnow.Month(), tnow.Day(), 0, 0, 0, 0, tnow.Location())
+	}
+	value := tnow
+	d.ins.TimeToDB(&value, tz)
+
+	Q := d.ins.TableQuote()
+
+	sep := fmt.Sprintf("%s = ? AND %s", Q, Q)
+	wheres := strings.Join(whereCols, sep)
+
+	query := fmt.Sprintf("UPDATE %s%s%s SET %s%s%s = ? WHERE %s%s%s = ? AND %s%s%s IS NULL",
+		Q, mi.table, Q, Q, sfi.column, Q, Q, wheres, Q, Q, sfi.column, Q)
+
+	d.ins.ReplaceMarks(&query)
+	res, err := q.Exec(query, append([]interface{}{value}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	num, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if num > 0 {
+		field := ind.FieldByIndex(sfi.fieldIndex)
+		if sfi.isFielder {
+			f := field.Addr().Interface().(Fielder)
+			f.SetRaw(tnow.In(DefaultTimeLoc))
+		} else if field.Kind() == reflect.Ptr {
+			v := tnow.In(DefaultTimeLoc)
+			field.Set(reflect.ValueOf(&v))
+		} else {
+			field.Set(reflect.ValueOf(tnow.In(DefaultTimeLoc)))
+		}
+	}
+	return num, nil
+}
+
 // update table-related record by querySet.
 // need querySet not struct reflect.Value to update related records.
 func (d *dbBase) UpdateBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condition, params Params, tz *time.Location) (int64, error) {
@@ -703,6 +947,9 @@ func (d *dbBase) UpdateBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Con
 		if fi, ok := mi.fields.GetByAny(col); !ok || !fi.dbcol {
 			panic(fmt.Errorf("wrong field/column name `%s`", col))
 		} else {
+			if fi.jsonValue {
+				val = getJSONParam(fi, val)
+			}
 			columns = append(columns, fi.column)
 			values = append(values, val)
 		}
@@ -715,6 +962,7 @@ func (d *dbBase) UpdateBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Con
 	tables := newDbTables(mi, d.ins)
 	if qs != nil {
 		tables.parseRelated(qs.related, qs.relDepth)
+		tables.trashed = qs.trashed
 	}
 
 	where, args := tables.getCondSQL(cond, false, tz)
@@ -752,6 +1000,21 @@ func (d *dbBase) UpdateBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Con
 		}
 	}
 
+	// keep optimistic locking version of updated rows moving
+	if vfi := mi.fields.version; vfi != nil {
+		found := false
+		for _, v := range columns {
+			if v == vfi.column {
+				found = true
+				break
+			}
+		}
+		if !found {
+			col := fmt.Sprintf("%s%s%s%s", T, Q, vfi.column, Q)
+			cols = append(cols, col+" = "+col+" + 1")
+		}
+	}
+
 	sets := strings.Join(cols, ", ") + " "
 
 	if d.ins.SupportUpdateJoin() {
@@ -810,6 +1073,7 @@ func (d *dbBase) DeleteBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Con
 
 	if qs != nil {
 		tables.parseRelated(qs.related, qs.relDepth)
+		tables.trashed = qs.trashed
 	}
 
 	if cond == nil || cond.IsEmpty() {
@@ -919,11 +1183,120 @@ func (d *dbBase) ReadBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condi
 		}
 	}
 
+	r, rs, err := d.queryRows(q, qs, mi, cond, tz, cols)
+	if err != nil {
+		return 0, err
+	}
+	defer rs.Close()
+
+	slice := ind
+
+	var cnt int64
+	for rs.Next() {
+		if one && cnt == 0 || !one {
+			mind, err := r.scan(rs)
+			if err != nil {
+				return 0, err
+			}
+
+			if one {
+				ind.Set(mind)
+			} else {
+				if cnt == 0 {
+					// you can use a empty & caped container list
+					// orm will not replace it
+					if ind.Len() != 0 {
+						// if container is not empty
+						// create a new one
+						slice = reflect.New(ind.Type()).Elem()
+					}
+				}
+
+				if isPtr {
+					slice = reflect.Append(slice, mind.Addr())
+				} else {
+					slice = reflect.Append(slice, mind)
+				}
+			}
+		}
+		cnt++
+	}
+
+	if !one {
+		if cnt > 0 {
+			ind.Set(slice)
+		} else {
+			// when a result is empty and container is nil
+			// to set a empty container
+			if ind.IsNil() {
+				ind.Set(reflect.MakeSlice(ind.Type(), 0, 0))
+			}
+		}
+	}
+
+	return cnt, nil
+}
+
+// read records one by one, fn receive the pointer of each new model.
+// stop and return the error of fn when it is not nil.
+func (d *dbBase) ReadIter(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condition, tz *time.Location, fn func(md interface{}) error) (int64, error) {
+	r, rs, err := d.queryRows(q, qs, mi, cond, tz, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer rs.Close()
+
+	var cnt int64
+	for rs.Next() {
+		mind, err := r.scan(rs)
+		if err != nil {
+			return cnt, err
+		}
+		if err := fn(mind.Addr().Interface()); err != nil {
+			return cnt, err
+		}
+		cnt++
+	}
+	return cnt, rs.Err()
+}
+
+// scanner of model rows with selected related models.
+type rowsScanner struct {
+	d        *dbBase
+	mi       *modelInfo
+	tables   *dbTables
+	tCols    []string
+	relTCols map[*dbTable][]string
+	refs     []interface{}
+	tz       *time.Location
+}
+
+// query model rows, return the scanner of rows.
+func (d *dbBase) queryRows(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condition, tz *time.Location, cols []string) (*rowsScanner, resultRows, error) {
 	rlimit := qs.limit
 	offset := qs.offset
 
 	Q := d.ins.TableQuote()
 
+	tables := newDbTables(mi, d.ins)
+	tables.parseRelated(qs.related, qs.relDepth)
+	tables.trashed = qs.trashed
+
+	var relCols map[string][]string
+	if len(cols) == 0 && len(qs.only) > 0 {
+		only := qs.only
+		for _, order := range qs.orders {
+			// order fields are needed by cursor
+			if order = strings.TrimPrefix(order, "-"); !strings.Contains(order, ExprSep) {
+				only = append(only, order)
+			}
+		}
+		var err error
+		if cols, relCols, err = tables.getOnlyColumns(only); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	var tCols []string
 	if len(cols) > 0 {
 		hasRel := len(qs.related) > 0 || qs.relDepth > 0
@@ -939,7 +1312,7 @@ func (d *dbBase) ReadBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condi
 					maps[fi.column] = true
 				}
 			} else {
-				return 0, fmt.Errorf("wrong field/column name `%s`", col)
+				return nil, nil, fmt.Errorf("wrong field/column name `%s`", col)
 			}
 		}
 		if hasRel {
@@ -959,20 +1332,24 @@ func (d *dbBase) ReadBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condi
 	sep := fmt.Sprintf("%s, T0.%s", Q, Q)
 	sels := fmt.Sprintf("T0.%s%s%s", Q, strings.Join(tCols, sep), Q)
 
-	tables := newDbTables(mi, d.ins)
-	tables.parseRelated(qs.related, qs.relDepth)
-
 	where, args := tables.getCondSQL(cond, false, tz)
 	groupBy := tables.getGroupSQL(qs.groups)
 	orderBy := tables.getOrderSQL(qs.orders)
 	limit := tables.getLimitSQL(mi, offset, rlimit)
 	join := tables.getJoinSQL()
 
+	// selected columns of related tables
+	relTCols := make(map[*dbTable][]string)
 	for _, tbl := range tables.tables {
 		if tbl.sel {
-			colsNum += len(tbl.mi.fields.dbcols)
+			rCols := tbl.mi.fields.dbcols
+			if c, ok := relCols[tbl.name]; ok {
+				rCols = c
+			}
+			relTCols[tbl] = rCols
+			colsNum += len(rCols)
 			sep := fmt.Sprintf("%s, %s.%s", Q, tbl.index, Q)
-			sels += fmt.Sprintf(", %s.%s%s%s", tbl.index, Q, strings.Join(tbl.mi.fields.dbcols, sep), Q)
+			sels += fmt.Sprintf(", %s.%s%s%s", tbl.index, Q, strings.Join(rCols, sep), Q)
 		}
 	}
 
@@ -988,18 +1365,9 @@ func (d *dbBase) ReadBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condi
 
 	d.ins.ReplaceMarks(&query)
 
-	var rs *sql.Rows
-	var err error
-	if qs != nil && qs.forContext {
-		rs, err = q.QueryContext(qs.ctx, query, args...)
-		if err != nil {
-			return 0, err
-		}
-	} else {
-		rs, err = q.Query(query, args...)
-		if err != nil {
-			return 0, err
-		}
+	rs, err := queryCached(qs.queryContext(), q, qs.queryCache(tables), query, args)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	refs := make([]interface{}, colsNum)
@@ -1008,110 +1376,76 @@ func (d *dbBase) ReadBatch(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condi
 		refs[i] = &ref
 	}
 
-	defer rs.Close()
-
-	slice := ind
+	r := &rowsScanner{d: d, mi: mi, tables: tables, tCols: tCols, relTCols: relTCols, refs: refs, tz: tz}
+	return r, rs, nil
+}
 
-	var cnt int64
-	for rs.Next() {
-		if one && cnt == 0 || !one {
-			if err := rs.Scan(refs...); err != nil {
-				return 0, err
-			}
+// scan current row into new model with selected related models.
+func (r *rowsScanner) scan(rs resultRows) (reflect.Value, error) {
+	d, mi, tz, refs := r.d, r.mi, r.tz, r.refs
+	if err := rs.Scan(refs...); err != nil {
+		return reflect.Value{}, err
+	}
 
-			elm := reflect.New(mi.addrField.Elem().Type())
-			mind := reflect.Indirect(elm)
+	elm := reflect.New(mi.addrField.Elem().Type())
+	mind := reflect.Indirect(elm)
 
-			cacheV := make(map[string]*reflect.Value)
-			cacheM := make(map[string]*modelInfo)
+	cacheV := make(map[string]*reflect.Value)
+	cacheM := make(map[string]*modelInfo)
 
-			d.setColsValues(mi, &mind, tCols, refs[:len(tCols)], tz)
-			trefs := refs[len(tCols):]
+	d.setColsValues(mi, &mind, r.tCols, refs[:len(r.tCols)], tz)
+	snapshotModel(d.ins, mi, mind, tz, nil)
+	trefs := refs[len(r.tCols):]
 
-			for _, tbl := range tables.tables {
-				// loop selected tables
-				if tbl.sel {
-					last := mind
-					names := ""
-					mmi := mi
-					// loop cascade models
-					for _, name := range tbl.names {
-						names += name
-						if val, ok := cacheV[names]; ok {
-							last = *val
-							mmi = cacheM[names]
-						} else {
-							fi := mmi.fields.GetByName(name)
-							lastm := mmi
-							mmi = fi.relModelInfo
-							field := last
-							if last.Kind() != reflect.Invalid {
-								field = reflect.Indirect(last.FieldByIndex(fi.fieldIndex))
-								if field.IsValid() {
-									d.setColsValues(mmi, &field, mmi.fields.dbcols, trefs[:len(mmi.fields.dbcols)], tz)
-									for _, fi := range mmi.fields.fieldsReverse {
-										if fi.inModel && fi.reverseFieldInfo.mi == lastm {
-											if fi.reverseFieldInfo != nil {
-												f := field.FieldByIndex(fi.fieldIndex)
-												if f.Kind() == reflect.Ptr {
-													f.Set(last.Addr())
-												}
-											}
+	for _, tbl := range r.tables.tables {
+		// loop selected tables
+		if tbl.sel {
+			last := mind
+			names := ""
+			mmi := mi
+			// loop cascade models
+			for _, name := range tbl.names {
+				names += name
+				if val, ok := cacheV[names]; ok {
+					last = *val
+					mmi = cacheM[names]
+				} else {
+					fi := mmi.fields.GetByName(name)
+					lastm := mmi
+					mmi = fi.relModelInfo
+					field := last
+					if last.Kind() != reflect.Invalid {
+						field = reflect.Indirect(last.FieldByIndex(fi.fieldIndex))
+						if field.IsValid() {
+							d.setColsValues(mmi, &field, r.relTCols[tbl], trefs[:len(r.relTCols[tbl])], tz)
+							for _, fi := range mmi.fields.fieldsReverse {
+								if fi.inModel && fi.reverseFieldInfo.mi == lastm {
+									if fi.reverseFieldInfo != nil {
+										f := field.FieldByIndex(fi.fieldIndex)
+										if f.Kind() == reflect.Ptr {
+											f.Set(last.Addr())
 										}
 									}
-									last = field
 								}
 							}
-							cacheV[names] = &field
-							cacheM[names] = mmi
+							last = field
 						}
 					}
-					trefs = trefs[len(mmi.fields.dbcols):]
-				}
-			}
-
-			if one {
-				ind.Set(mind)
-			} else {
-				if cnt == 0 {
-					// you can use a empty & caped container list
-					// orm will not replace it
-					if ind.Len() != 0 {
-						// if container is not empty
-						// create a new one
-						slice = reflect.New(ind.Type()).Elem()
-					}
-				}
-
-				if isPtr {
-					slice = reflect.Append(slice, mind.Addr())
-				} else {
-					slice = reflect.Append(slice, mind)
+					cacheV[names] = &field
+					cacheM[names] = mmi
 				}
 			}
+			trefs = trefs[len(r.relTCols[tbl]):]
 		}
-		cnt++
 	}
-
-	if !one {
-		if cnt > 0 {
-			ind.Set(slice)
-		} else {
-			// when a result is empty and container is nil
-			// to set a empty container
-			if ind.IsNil() {
-				ind.Set(reflect.MakeSlice(ind.Type(), 0, 0))
-			}
-		}
-	}
-
-	return cnt, nil
+	return mind, nil
 }
 
 // excute count sql and return count result int64.
 func (d *dbBase) Count(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condition, tz *time.Location) (cnt int64, err error) {
 	tables := newDbTables(mi, d.ins)
 	tables.parseRelated(qs.related, qs.relDepth)
+	tables.trashed = qs.trashed
 
 	where, args := tables.getCondSQL(cond, false, tz)
 	groupBy := tables.getGroupSQL(qs.groups)
@@ -1128,13 +1462,7 @@ func (d *dbBase) Count(q dbQuerier, qs *querySet, mi *modelInfo, cond *Condition
 
 	d.ins.ReplaceMarks(&query)
 
-	var row *sql.Row
-	if qs != nil && qs.forContext {
-		row = q.QueryRowContext(qs.ctx, query, args...)
-	} else {
-		row = q.QueryRow(query, args...)
-	}
-	err = row.Scan(&cnt)
+	err = queryRowCached(qs.queryContext(), q, qs.queryCache(tables), query, args, &cnt)
 	return
 }
 
@@ -1160,11 +1488,28 @@ func (d *dbBase) GenerateOperatorSQL(mi *modelInfo, fi *fieldInfo, operator stri
 			panic(fmt.Errorf("operator `%s` need 2 args not %d", operator, len(params)))
 		}
 		sql = "BETWEEN ? AND ?"
+	case "isnull":
+		if len(params) > 1 {
+			panic(fmt.Errorf("operator `%s` need 1 args not %d", operator, len(params)))
+		}
+		b, ok := arg.(bool)
+		if !ok {
+			panic(fmt.Errorf("operator `%s` need a bool value not `%T`", operator, arg))
+		}
+		if b {
+			sql = "IS NULL"
+		} else {
+			sql = "IS NOT NULL"
+		}
+		params = nil
 	default:
 		if len(params) > 1 {
 			panic(fmt.Errorf("operator `%s` need 1 args not %d", operator, len(params)))
 		}
 		sql = d.ins.OperatorSQL(operator)
+		if sql == "" {
+			panic(fmt.Errorf("operator `%s` is not supported by driver", operator))
+		}
 		switch operator {
 		case "exact":
 			if arg == nil {
@@ -1182,17 +1527,12 @@ func (d *dbBase) GenerateOperatorSQL(mi *modelInfo, fi *fieldInfo, operator stri
 				param = fmt.Sprintf("%%%s", param)
 			}
 			params[0] = param
-		case "isnull":
-			if b, ok := arg.(bool); ok {
-				if b {
-					sql = "IS NULL"
-				} else {
-					sql = "IS NOT NULL"
-				}
-				params = nil
-			} else {
-				panic(fmt.Errorf("operator `%s` need a bool value not `%T`", operator, arg))
+		case "year", "month", "day", "week_day":
+			n, err := StrTo(ToStr(arg)).Int64()
+			if err != nil {
+				panic(fmt.Errorf("operator `%s` need an integer value not `%v`", operator, arg))
 			}
+			params[0] = n
 		}
 	}
 	return sql, params
@@ -1203,6 +1543,11 @@ func (d *dbBase) GenerateOperatorLeftCol(*fieldInfo, string, *string) {
 	// default not use
 }
 
+// generate sql of value in json column at path, empty when json path is not supported.
+func (d *dbBase) GenerateJSONPathCol(string, []string) (string, []interface{}) {
+	return "", nil
+}
+
 // set values to struct column.
 func (d *dbBase) setColsValues(mi *modelInfo, ind *reflect.Value, cols []string, values []interface{}, tz *time.Location) {
 	for i, column := range cols {
@@ -1391,6 +1736,16 @@ func (d *dbBase) setFieldValue(fi *fieldInfo, value interface{}, field reflect.V
 
 setValue:
 	switch {
+	case fi.jsonValue:
+		if s, ok := value.(string); !ok || s == "" {
+			field.Set(reflect.Zero(field.Type()))
+		} else {
+			v := reflect.New(field.Type())
+			if err := json.Unmarshal([]byte(s), v.Interface()); err != nil {
+				return nil, fmt.Errorf("json value `%s` set to field `%s` failed, err: %s", s, fi.fullName, err)
+			}
+			field.Set(v.Elem())
+		}
 	case fieldType == TypeBooleanField:
 		if isNative {
 			if nb, ok := field.Interface().(sql.NullBool); ok {
@@ -1609,6 +1964,7 @@ func (d *dbBase) ReadValues(q dbQuerier, qs *querySet, mi *modelInfo, cond *Cond
 	}
 
 	tables := newDbTables(mi, d.ins)
+	tables.trashed = qs.trashed
 
 	var (
 		cols  []string
@@ -1655,7 +2011,7 @@ func (d *dbBase) ReadValues(q dbQuerier, qs *querySet, mi *modelInfo, cond *Cond
 
 	d.ins.ReplaceMarks(&query)
 
-	rs, err := q.Query(query, args...)
+	rs, err := queryCached(qs.queryContext(), q, qs.queryCache(tables), query, args)
 	if err != nil {
 		return 0, err
 	}
@@ -1754,6 +2110,11 @@ func (d *dbBase) SupportUpdateJoin() bool {
 	return true
 }
 
+// flag of nested transaction by savepoint.
+func (d *dbBase) SupportSavepoint() bool {
+	return false
+}
+
 func (d *dbBase) MaxLimit() uint64 {
 	return 18446744073709551615
 }
@@ -1845,6 +2206,20 @@ func (d *dbBase) GetColumns(db dbQuerier, table string) (map[string][3]string, e
 	return columns, nil
 }
 
+// get columns detail in table, default only knows name, type and nullable.
+func (d *dbBase) GetColumnsInfo(db dbQuerier, table string) (map[string]dbColumn, error) {
+	columns, err := d.ins.GetColumns(db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make(map[string]dbColumn, len(columns))
+	for name, col := range columns {
+		infos[name] = dbColumn{Name: col[0], Type: col[1], Null: col[2] == "YES"}
+	}
+	return infos, nil
+}
+
 // not implement.
 func (d *dbBase) OperatorSQL(operator string) string {
 	panic(ErrNotImplement)
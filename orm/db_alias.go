@@ -114,6 +114,9 @@ type alias struct {
 	DbBaser      dbBaser
 	TZ           *time.Location
 	Engine       string
+	Replicas     []*alias
+	Balancer     ReplicaBalancer
+	interceptors []QueryInterceptor
 }
 
 func detectTZ(al *alias) {
@@ -15,19 +15,21 @@
 package orm
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
 // mysql operators.
 var mysqlOperators = map[string]string{
-	"exact":     "= ?",
-	"iexact":    "LIKE ?",
-	"contains":  "LIKE BINARY ?",
-	"icontains": "LIKE ?",
-	// "regex":       "REGEXP BINARY ?",
-	// "iregex":      "REGEXP ?",
+	"exact":       "= ?",
+	"iexact":      "LIKE ?",
+	"contains":    "LIKE BINARY ?",
+	"icontains":   "LIKE ?",
+	"regex":       "REGEXP BINARY ?",
+	"iregex":      "REGEXP ?",
 	"gt":          "> ?",
 	"gte":         ">= ?",
 	"lt":          "< ?",
@@ -38,6 +40,11 @@ var mysqlOperators = map[string]string{
 	"endswith":    "LIKE BINARY ?",
 	"istartswith": "LIKE ?",
 	"iendswith":   "LIKE ?",
+	"year":        "= ?",
+	"month":       "= ?",
+	"day":         "= ?",
+	"week_day":    "= ?",
+	"search":      "AGAINST (?)",
 }
 
 // mysql column field types.
@@ -74,6 +81,50 @@ func (d *dbBaseMysql) OperatorSQL(operator string) string {
 	return mysqlOperators[operator]
 }
 
+// generate date part and full-text search sql for mysql,
+// search need FULLTEXT index on the column.
+func (d *dbBaseMysql) GenerateOperatorLeftCol(fi *fieldInfo, operator string, leftCol *string) {
+	mysqlOperatorLeftCol(operator, leftCol)
+}
+
+func mysqlOperatorLeftCol(operator string, leftCol *string) {
+	switch operator {
+	case "year":
+		*leftCol = fmt.Sprintf("YEAR(%s)", *leftCol)
+	case "month":
+		*leftCol = fmt.Sprintf("MONTH(%s)", *leftCol)
+	case "day":
+		*leftCol = fmt.Sprintf("DAYOFMONTH(%s)", *leftCol)
+	case "week_day":
+		*leftCol = fmt.Sprintf("DAYOFWEEK(%s)", *leftCol)
+	case "search":
+		*leftCol = fmt.Sprintf("MATCH (%s)", *leftCol)
+	}
+}
+
+// generate sql of value in json column at path, the value is unquoted text.
+func (d *dbBaseMysql) GenerateJSONPathCol(leftCol string, path []string) (string, []interface{}) {
+	return mysqlJSONPathCol(leftCol, path)
+}
+
+func mysqlJSONPathCol(leftCol string, path []string) (string, []interface{}) {
+	p := "$"
+	for _, key := range path {
+		if _, err := strconv.ParseUint(key, 10, 32); err == nil {
+			// index of array
+			p += "[" + key + "]"
+		} else {
+			p += "." + strconv.Quote(key)
+		}
+	}
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, ?))", leftCol), []interface{}{p}
+}
+
+// mysql supports savepoint.
+func (d *dbBaseMysql) SupportSavepoint() bool {
+	return true
+}
+
 // get mysql table field types.
 func (d *dbBaseMysql) DbTypes() map[string]string {
 	return mysqlTypes
@@ -99,6 +150,43 @@ func (d *dbBaseMysql) IndexExists(db dbQuerier, table string, name string) bool
 	return cnt > 0
 }
 
+// get columns detail of table for mysql.
+func (d *dbBaseMysql) GetColumnsInfo(db dbQuerier, table string) (map[string]dbColumn, error) {
+	return getMysqlColumnsInfo(db, table)
+}
+
+// read columns detail from mysql information schema.
+func getMysqlColumnsInfo(db dbQuerier, table string) (map[string]dbColumn, error) {
+	rows, err := db.Query("SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY FROM information_schema.columns "+
+		"WHERE table_schema = DATABASE() AND table_name = ?", table)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	columns := make(map[string]dbColumn)
+	for rows.Next() {
+		var (
+			name, typ, null, key string
+			def                  sql.NullString
+		)
+		if err := rows.Scan(&name, &typ, &null, &def, &key); err != nil {
+			return nil, err
+		}
+		columns[name] = dbColumn{
+			Name:       name,
+			Type:       typ,
+			Null:       null == "YES",
+			Default:    def.String,
+			HasDefault: def.Valid,
+			Unique:     key == "UNI" || key == "PRI",
+		}
+	}
+
+	return columns, nil
+}
+
 // InsertOrUpdate a row
 // If your primary key or unique column conflict will update
 // If no will insert
@@ -175,6 +263,23 @@ func (d *dbBaseMysql) InsertOrUpdate(q dbQuerier, mi *modelInfo, ind reflect.Val
 	return id, err
 }
 
+// get the clause of insert sql, mysql update cols on conflict of any unique key.
+func (d *dbBaseMysql) UpsertSQL(conflicts []string, cols []string) (string, error) {
+	return mysqlUpsertSQL(cols)
+}
+
+// get mysql clause updating cols with inserted values on duplicate key.
+func mysqlUpsertSQL(cols []string) (string, error) {
+	if len(cols) == 0 {
+		return "", fmt.Errorf("mysql upsert must have update columns")
+	}
+	sets := make([]string, len(cols))
+	for i, col := range cols {
+		sets[i] = fmt.Sprintf("`%s` = VALUES(`%s`)", col, col)
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+}
+
 // create new mysql dbBaser.
 func newdbBaseMysql() dbBaser {
 	b := new(dbBaseMysql)
@@ -96,6 +96,11 @@ func (d *dbBaseOracle) IndexExists(db dbQuerier, table string, name string) bool
 	return cnt > 0
 }
 
+// UpsertSQL oracle nonsupport upsert by insert sql.
+func (d *dbBaseOracle) UpsertSQL(conflicts []string, cols []string) (string, error) {
+	return "", fmt.Errorf("`oracle` nonsupport InsertOrUpdateMulti")
+}
+
 // execute insert sql with given struct and given values.
 // insert the given values, not the field values in struct.
 func (d *dbBaseOracle) InsertValue(q dbQuerier, mi *modelInfo, isMulti bool, names []string, values []interface{}) (int64, error) {
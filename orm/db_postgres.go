@@ -15,8 +15,10 @@
 package orm
 
 import (
+	"database/sql"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // postgresql operators.
@@ -35,6 +37,13 @@ var postgresOperators = map[string]string{
 	"endswith":    "LIKE ?",
 	"istartswith": "LIKE UPPER(?)",
 	"iendswith":   "LIKE UPPER(?)",
+	"regex":       "~ ?",
+	"iregex":      "~* ?",
+	"year":        "= ?",
+	"month":       "= ?",
+	"day":         "= ?",
+	"week_day":    "= ?",
+	"search":      "@@ plainto_tsquery(?)",
 }
 
 // postgresql column field types.
@@ -59,6 +68,7 @@ var postgresTypes = map[string]string{
 	"float64-decimal": "numeric(%d, %d)",
 	"json":            "json",
 	"jsonb":           "jsonb",
+	"uuid":            "uuid",
 }
 
 // postgresql dbBaser.
@@ -80,14 +90,39 @@ func (d *dbBasePostgres) GenerateOperatorLeftCol(fi *fieldInfo, operator string,
 		*leftCol = fmt.Sprintf("%s::text", *leftCol)
 	case "iexact", "icontains", "istartswith", "iendswith":
 		*leftCol = fmt.Sprintf("UPPER(%s::text)", *leftCol)
+	case "regex", "iregex":
+		*leftCol = fmt.Sprintf("%s::text", *leftCol)
+	case "year", "month", "day":
+		*leftCol = fmt.Sprintf("EXTRACT(%s FROM %s)", strings.ToUpper(operator), *leftCol)
+	case "week_day":
+		// same as mysql DAYOFWEEK, 1 is sunday
+		*leftCol = fmt.Sprintf("(EXTRACT(DOW FROM %s) + 1)", *leftCol)
+	case "search":
+		*leftCol = fmt.Sprintf("to_tsvector(%s::text)", *leftCol)
 	}
 }
 
+// generate sql of value in json column at path, the value is text.
+// path is array of keys, index of array is a key too.
+func (d *dbBasePostgres) GenerateJSONPathCol(leftCol string, path []string) (string, []interface{}) {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		key = strings.Replace(key, `\`, `\\`, -1)
+		keys[i] = `"` + strings.Replace(key, `"`, `\"`, -1) + `"`
+	}
+	return fmt.Sprintf("(%s #>> ?)", leftCol), []interface{}{"{" + strings.Join(keys, ",") + "}"}
+}
+
 // postgresql unsupports updating joined record.
 func (d *dbBasePostgres) SupportUpdateJoin() bool {
 	return false
 }
 
+// postgresql supports savepoint.
+func (d *dbBasePostgres) SupportSavepoint() bool {
+	return true
+}
+
 func (d *dbBasePostgres) MaxLimit() uint64 {
 	return 0
 }
@@ -167,6 +202,56 @@ func (d *dbBasePostgres) ShowColumnsQuery(table string) string {
 	return fmt.Sprintf("SELECT column_name, data_type, is_nullable FROM information_schema.columns where table_schema NOT IN ('pg_catalog', 'information_schema') and table_name = '%s'", table)
 }
 
+// get columns detail of table for postgresql.
+func (d *dbBasePostgres) GetColumnsInfo(db dbQuerier, table string) (map[string]dbColumn, error) {
+	rows, err := db.Query(`SELECT c.column_name, c.data_type, c.character_maximum_length, c.numeric_precision, c.numeric_scale, c.is_nullable, c.column_default,
+	EXISTS (SELECT 1 FROM pg_index i JOIN pg_class t ON t.oid = i.indrelid JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = i.indkey[0]
+		WHERE t.relname = c.table_name AND a.attname = c.column_name AND i.indisunique AND i.indnatts = 1)
+	FROM information_schema.columns c WHERE c.table_schema NOT IN ('pg_catalog', 'information_schema') AND c.table_name = $1`, table)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	columns := make(map[string]dbColumn)
+	for rows.Next() {
+		var (
+			name, typ, null             string
+			size, precision, scale, def sql.NullString
+			unique                      bool
+		)
+		if err := rows.Scan(&name, &typ, &size, &precision, &scale, &null, &def, &unique); err != nil {
+			return nil, err
+		}
+
+		switch typ {
+		case "character varying":
+			typ = "varchar"
+		case "character":
+			typ = "char"
+		case "boolean":
+			typ = "bool"
+		}
+		if size.Valid {
+			typ = fmt.Sprintf("%s(%s)", typ, size.String)
+		} else if typ == "numeric" && precision.Valid {
+			typ = fmt.Sprintf("%s(%s, %s)", typ, precision.String, scale.String)
+		}
+
+		columns[name] = dbColumn{
+			Name:       name,
+			Type:       typ,
+			Null:       null == "YES",
+			Default:    def.String,
+			HasDefault: def.Valid,
+			Unique:     unique,
+		}
+	}
+
+	return columns, nil
+}
+
 // get column types of postgresql.
 func (d *dbBasePostgres) DbTypes() map[string]string {
 	return postgresTypes
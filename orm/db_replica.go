@@ -0,0 +1,79 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+)
+
+// ReplicaBalancer choose the replica used by a read query.
+// Next receive the number of replicas and return the index of chosen one.
+type ReplicaBalancer interface {
+	Next(n int) int
+}
+
+// RoundRobinBalancer choose replicas in turn.
+type RoundRobinBalancer struct {
+	count uint64
+}
+
+// Next return the next replica index.
+func (b *RoundRobinBalancer) Next(n int) int {
+	return int((atomic.AddUint64(&b.count, 1) - 1) % uint64(n))
+}
+
+// RandomBalancer choose replica randomly.
+type RandomBalancer struct{}
+
+// Next return a random replica index.
+func (b RandomBalancer) Next(n int) int {
+	return rand.Intn(n)
+}
+
+// RegisterReplica register a read replica database for primary alias.
+// the replica is registered as alias too, so it can be used by Ormer.Using.
+// reads of QuerySeter and RawSeter.QueryRows are routed to replicas,
+// writes, ForUpdate and queries in transaction stay on primary.
+func RegisterReplica(primaryName, aliasName, driverName, dataSource string, params ...int) error {
+	primary, ok := dataBaseCache.get(primaryName)
+	if !ok {
+		return fmt.Errorf("register replica `%s`, unknown primary db alias name `%s`", aliasName, primaryName)
+	}
+	if dr, ok := drivers[driverName]; !ok || dr != primary.Driver {
+		return fmt.Errorf("register replica `%s`, driver name `%s` must be same as primary", aliasName, driverName)
+	}
+
+	if err := RegisterDataBase(aliasName, driverName, dataSource, params...); err != nil {
+		return err
+	}
+
+	al := getDbAlias(aliasName)
+	primary.Replicas = append(primary.Replicas, al)
+	if primary.Balancer == nil {
+		primary.Balancer = new(RoundRobinBalancer)
+	}
+	return nil
+}
+
+// SetReplicaBalancer change the replica balancer of primary alias,
+// default is RoundRobinBalancer.
+func SetReplicaBalancer(primaryName string, b ReplicaBalancer) {
+	al := getDbAlias(primaryName)
+	al.Balancer = b
+}
+
+// choose replica alias for read query, nil means no replica.
+func (al *alias) replica() *alias {
+	switch n := len(al.Replicas); n {
+	case 0:
+		return nil
+	case 1:
+		return al.Replicas[0]
+	default:
+		return al.Replicas[al.Balancer.Next(n)%n]
+	}
+}
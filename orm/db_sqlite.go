@@ -35,6 +35,10 @@ var sqliteOperators = map[string]string{
 	"endswith":    "LIKE ? ESCAPE '\\'",
 	"istartswith": "LIKE ? ESCAPE '\\'",
 	"iendswith":   "LIKE ? ESCAPE '\\'",
+	"year":        "= ?",
+	"month":       "= ?",
+	"day":         "= ?",
+	"week_day":    "= ?",
 }
 
 // sqlite column types.
@@ -71,9 +75,25 @@ func (d *dbBaseSqlite) OperatorSQL(operator string) string {
 	return sqliteOperators[operator]
 }
 
+// sqlite date part formats of strftime.
+var sqliteDateParts = map[string]string{
+	"year":     "%Y",
+	"month":    "%m",
+	"day":      "%d",
+	"week_day": "%w",
+}
+
 // generate functioned sql for sqlite.
-// only support DATE(text).
+// support DATE(text) and date part by strftime.
 func (d *dbBaseSqlite) GenerateOperatorLeftCol(fi *fieldInfo, operator string, leftCol *string) {
+	if format, ok := sqliteDateParts[operator]; ok {
+		*leftCol = fmt.Sprintf("CAST(strftime('%s', %s) AS INTEGER)", format, *leftCol)
+		if operator == "week_day" {
+			// same as mysql DAYOFWEEK, 1 is sunday
+			*leftCol = fmt.Sprintf("(%s + 1)", *leftCol)
+		}
+		return
+	}
 	if fi.fieldType == TypeDateField {
 		*leftCol = fmt.Sprintf("DATE(%s)", *leftCol)
 	}
@@ -84,6 +104,11 @@ func (d *dbBaseSqlite) SupportUpdateJoin() bool {
 	return false
 }
 
+// sqlite supports savepoint.
+func (d *dbBaseSqlite) SupportSavepoint() bool {
+	return true
+}
+
 // max int in sqlite.
 func (d *dbBaseSqlite) MaxLimit() uint64 {
 	return 9223372036854775807
@@ -120,6 +145,81 @@ func (d *dbBaseSqlite) GetColumns(db dbQuerier, table string) (map[string][3]str
 	return columns, nil
 }
 
+// get columns detail in sqlite.
+func (d *dbBaseSqlite) GetColumnsInfo(db dbQuerier, table string) (map[string]dbColumn, error) {
+	rows, err := db.Query(fmt.Sprintf("pragma table_info('%s')", table))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]dbColumn)
+	for rows.Next() {
+		var (
+			tmp, name, typ, def sql.NullString
+			notNull             int
+		)
+		if err := rows.Scan(&tmp, &name, &typ, &notNull, &def, &tmp); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		columns[name.String] = dbColumn{
+			Name:       name.String,
+			Type:       typ.String,
+			Null:       notNull == 0,
+			Default:    def.String,
+			HasDefault: def.Valid,
+		}
+	}
+	rows.Close()
+
+	// mark single column unique indexes
+	rows, err = db.Query(fmt.Sprintf("pragma index_list('%s')", table))
+	if err != nil {
+		return nil, err
+	}
+	var uniques []string
+	for rows.Next() {
+		var (
+			tmp, index sql.NullString
+			unique     int
+		)
+		if err := rows.Scan(&tmp, &index, &unique, &tmp, &tmp); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if unique == 1 {
+			uniques = append(uniques, index.String)
+		}
+	}
+	rows.Close()
+
+	for _, index := range uniques {
+		rows, err = db.Query(fmt.Sprintf("pragma index_info('%s')", index))
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for rows.Next() {
+			var tmp, name sql.NullString
+			if err := rows.Scan(&tmp, &tmp, &name); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			names = append(names, name.String)
+		}
+		rows.Close()
+
+		if len(names) == 1 {
+			if col, ok := columns[names[0]]; ok {
+				col.Unique = true
+				columns[names[0]] = col
+			}
+		}
+	}
+
+	return columns, nil
+}
+
 // get show columns sql in sqlite.
 func (d *dbBaseSqlite) ShowColumnsQuery(table string) string {
 	return fmt.Sprintf("pragma table_info('%s')", table)
@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/raryanda/go/utility"
 )
 
 // table info struct.
@@ -35,11 +37,38 @@ type dbTable struct {
 
 // tables collection struct, contains some tables.
 type dbTables struct {
-	tablesM map[string]*dbTable
-	tables  []*dbTable
-	mi      *modelInfo
-	base    dbBaser
-	skipEnd bool
+	tablesM     map[string]*dbTable
+	tables      []*dbTable
+	mi          *modelInfo
+	base        dbBaser
+	skipEnd     bool
+	trashed     int
+	annotations map[string]*annotation
+}
+
+// scopes of soft deleted rows.
+const (
+	trashedWithout = iota // exclude soft deleted rows, default
+	trashedWith           // include soft deleted rows
+	trashedOnly           // only soft deleted rows
+)
+
+// generate soft delete condition of main table.
+func (t *dbTables) getTrashedSQL() string {
+	fi := t.mi.fields.softDelete
+	if fi == nil {
+		return ""
+	}
+
+	Q := t.base.TableQuote()
+
+	switch t.trashed {
+	case trashedWithout:
+		return fmt.Sprintf("T0.%s%s%s IS NULL", Q, fi.column, Q)
+	case trashedOnly:
+		return fmt.Sprintf("T0.%s%s%s IS NOT NULL", Q, fi.column, Q)
+	}
+	return ""
 }
 
 // set table info to collection.
@@ -206,6 +235,11 @@ func (t *dbTables) getJoinSQL() (join string) {
 
 		join += fmt.Sprintf("%s%s%s %s ON %s.%s%s%s = %s.%s%s%s ", Q, table, Q, t2,
 			t2, Q, c2, Q, t1, Q, c1, Q)
+
+		// soft deleted rows of joined table are excluded unless trashed rows are included
+		if fi := jt.mi.fields.softDelete; fi != nil && t.trashed != trashedWith {
+			join += fmt.Sprintf("AND %s.%s%s%s IS NULL ", t2, Q, fi.column, Q)
+		}
 	}
 	return
 }
@@ -325,8 +359,37 @@ loopFor:
 	return
 }
 
+// parse expression of lookup in json field, such as meta__settings__theme,
+// the exprs after the json field are keys of the path.
+func (t *dbTables) parseJSONPath(mi *modelInfo, exprs []string) (index string, info *fieldInfo, path []string, success bool) {
+	for i := len(exprs) - 1; i > 0; i-- {
+		if index, _, info, success = t.parseExprs(mi, exprs[:i]); success {
+			if info.fieldType == TypeJSONField || info.fieldType == TypeJsonbField {
+				return index, info, exprs[i:], true
+			}
+			break
+		}
+	}
+	return "", nil, nil, false
+}
+
 // generate condition sql.
 func (t *dbTables) getCondSQL(cond *Condition, sub bool, tz *time.Location) (where string, params []interface{}) {
+	if !sub {
+		where, params = t.getCondSQL(cond, true, tz)
+		if trashed := t.getTrashedSQL(); trashed != "" {
+			if where != "" {
+				where = fmt.Sprintf("( %s) AND %s ", where, trashed)
+			} else {
+				where = trashed + " "
+			}
+		}
+		if where != "" {
+			where = "WHERE " + where
+		}
+		return
+	}
+
 	if cond == nil || cond.IsEmpty() {
 		return
 	}
@@ -358,14 +421,38 @@ func (t *dbTables) getCondSQL(cond *Condition, sub bool, tz *time.Location) (whe
 
 			num := len(exprs) - 1
 			operator := ""
-			if operators[exprs[num]] {
+			if num > 0 && operators[exprs[num]] {
 				operator = exprs[num]
 				exprs = exprs[:num]
 			}
 
-			index, _, fi, suc := t.parseExprs(mi, exprs)
-			if !suc {
-				panic(fmt.Errorf("unknown field/column name `%s`", strings.Join(p.exprs, ExprSep)))
+			var (
+				fi      *fieldInfo
+				leftCol string
+				path    []string
+			)
+			if a, ok := t.annotations[strings.Join(exprs, ExprSep)]; ok {
+				// condition on annotated aggregation, used by HAVING
+				fi = a.fi
+				leftCol = a.sql
+			} else {
+				index, _, info, suc := t.parseExprs(mi, exprs)
+				if !suc {
+					index, info, path, suc = t.parseJSONPath(mi, exprs)
+				}
+				if !suc {
+					panic(fmt.Errorf("unknown field/column name `%s`", strings.Join(p.exprs, ExprSep)))
+				}
+				fi = info
+				leftCol = fmt.Sprintf("%s.%s%s%s", index, Q, fi.column, Q)
+			}
+			if len(path) > 0 {
+				col, ps := t.base.GenerateJSONPathCol(leftCol, path)
+				if col == "" {
+					panic(fmt.Errorf("json path lookup `%s` is not supported by driver", strings.Join(p.exprs, ExprSep)))
+				}
+				leftCol = col
+				params = append(params, ps...)
 			}
 
 			if operator == "" {
@@ -376,11 +463,13 @@ func (t *dbTables) getCondSQL(cond *Condition, sub bool, tz *time.Location) (whe
 			var args []interface{}
 			if p.isRaw {
 				operSQL = p.sql
+			} else if sql, ps, ok := t.getExprOperatorSQL(operator, p.args, tz); ok {
+				// compare with column or sub query
+				operSQL, args = sql, ps
 			} else {
 				operSQL, args = t.base.GenerateOperatorSQL(mi, fi, operator, p.args, tz)
 			}
 
-			leftCol := fmt.Sprintf("%s.%s%s%s", index, Q, fi.column, Q)
 			t.base.GenerateOperatorLeftCol(fi, operator, &leftCol)
 
 			where += fmt.Sprintf("%s %s ", leftCol, operSQL)
@@ -389,10 +478,57 @@ func (t *dbTables) getCondSQL(cond *Condition, sub bool, tz *time.Location) (whe
 		}
 	}
 
-	if !sub && where != "" {
-		where = "WHERE " + where
-	}
+	return
+}
+
+// resolve fields selected by QuerySeter.Only into columns of main model,
+// and columns of selected related tables by table name.
+// pk is always selected.
+func (t *dbTables) getOnlyColumns(fields []string) (cols []string, relCols map[string][]string, err error) {
+	cols = []string{t.mi.fields.pk.column}
+	for _, field := range fields {
+		exs := strings.Split(field, ExprSep)
+		if len(exs) == 1 {
+			fi, ok := t.mi.fields.GetByAny(field)
+			if !ok || !fi.dbcol {
+				return nil, nil, fmt.Errorf("wrong field/column name `%s`", field)
+			}
+			if !utility.Contains(cols, fi.column) {
+				cols = append(cols, fi.column)
+			}
+			continue
+		}
 
+		mmi := t.mi
+		names := make([]string, 0, len(exs)-1)
+		for _, ex := range exs[:len(exs)-1] {
+			fi, ok := mmi.fields.GetByAny(ex)
+			if !ok || !fi.rel || fi.fieldType == RelManyToMany {
+				return nil, nil, fmt.Errorf("wrong field/column name `%s`", field)
+			}
+			names = append(names, fi.name)
+			mmi = fi.relModelInfo
+		}
+
+		fi, ok := mmi.fields.GetByAny(exs[len(exs)-1])
+		if !ok || !fi.dbcol {
+			return nil, nil, fmt.Errorf("wrong field/column name `%s`", field)
+		}
+
+		name := strings.Join(names, ExprSep)
+		if jt, ok := t.get(name); !ok || !jt.sel {
+			return nil, nil, fmt.Errorf("field `%s` need related `%s` to be selected", field, name)
+		}
+		if relCols == nil {
+			relCols = make(map[string][]string)
+		}
+		if _, ok := relCols[name]; !ok {
+			relCols[name] = []string{mmi.fields.pk.column}
+		}
+		if !utility.Contains(relCols[name], fi.column) {
+			relCols[name] = append(relCols[name], fi.column)
+		}
+	}
 	return
 }
 
@@ -25,11 +25,26 @@ type dbBaseTidb struct {
 
 var _ dbBaser = new(dbBaseTidb)
 
+// get the clause of insert sql, tidb update cols on conflict of any unique key as mysql.
+func (d *dbBaseTidb) UpsertSQL(conflicts []string, cols []string) (string, error) {
+	return mysqlUpsertSQL(cols)
+}
+
 // get mysql operator.
 func (d *dbBaseTidb) OperatorSQL(operator string) string {
 	return mysqlOperators[operator]
 }
 
+// generate date part and full-text search sql for tidb.
+func (d *dbBaseTidb) GenerateOperatorLeftCol(fi *fieldInfo, operator string, leftCol *string) {
+	mysqlOperatorLeftCol(operator, leftCol)
+}
+
+// generate sql of value in json column at path as mysql.
+func (d *dbBaseTidb) GenerateJSONPathCol(leftCol string, path []string) (string, []interface{}) {
+	return mysqlJSONPathCol(leftCol, path)
+}
+
 // get mysql table field types.
 func (d *dbBaseTidb) DbTypes() map[string]string {
 	return mysqlTypes
@@ -46,6 +61,11 @@ func (d *dbBaseTidb) ShowColumnsQuery(table string) string {
 		"WHERE table_schema = DATABASE() AND table_name = '%s'", table)
 }
 
+// get columns detail of table for mysql.
+func (d *dbBaseTidb) GetColumnsInfo(db dbQuerier, table string) (map[string]dbColumn, error) {
+	return getMysqlColumnsInfo(db, table)
+}
+
 // execute sql to check index exist.
 func (d *dbBaseTidb) IndexExists(db dbQuerier, table string, name string) bool {
 	row := db.QueryRow("SELECT count(*) FROM information_schema.statistics "+
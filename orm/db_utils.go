@@ -15,6 +15,7 @@
 package orm
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"time"
@@ -28,10 +29,41 @@ func getDbAlias(name string) *alias {
 	panic(fmt.Errorf("unknown DataBase alias name %s", name))
 }
 
+// column detail read from database.
+type dbColumn struct {
+	Name       string
+	Type       string
+	Null       bool
+	Default    string
+	HasDefault bool
+	Unique     bool
+}
+
 // get pk column info.
 func getExistPk(mi *modelInfo, ind reflect.Value) (column string, value interface{}, exist bool) {
 	fi := mi.fields.pk
+	value, exist = getPkValue(fi, ind)
+	column = fi.column
+	return
+}
 
+// get columns and values of all pk fields, exist is false when any of them is empty.
+func getExistPks(mi *modelInfo, ind reflect.Value) (columns []string, values []interface{}, exist bool) {
+	columns = make([]string, 0, len(mi.fields.pks))
+	values = make([]interface{}, 0, len(mi.fields.pks))
+	for _, fi := range mi.fields.pks {
+		value, ok := getPkValue(fi, ind)
+		if !ok {
+			return nil, nil, false
+		}
+		columns = append(columns, fi.column)
+		values = append(values, value)
+	}
+	return columns, values, true
+}
+
+// get value of pk field.
+func getPkValue(fi *fieldInfo, ind reflect.Value) (value interface{}, exist bool) {
 	v := ind.FieldByIndex(fi.fieldIndex)
 	if fi.fieldType&IsPositiveIntegerField > 0 {
 		vu := v.Uint()
@@ -42,17 +74,30 @@ func getExistPk(mi *modelInfo, ind reflect.Value) (column string, value interfac
 		exist = true
 		value = vu
 	} else if fi.fieldType&IsRelField > 0 {
-		_, value, exist = getExistPk(fi.relModelInfo, reflect.Indirect(v))
+		if v = reflect.Indirect(v); v.IsValid() {
+			_, value, exist = getExistPk(fi.relModelInfo, v)
+		}
 	} else {
 		vu := v.String()
 		exist = vu != ""
 		value = vu
 	}
-
-	column = fi.column
 	return
 }
 
+// get columns of field/column names.
+func getColumns(mi *modelInfo, names []string) ([]string, error) {
+	columns := make([]string, 0, len(names))
+	for _, name := range names {
+		fi, ok := mi.fields.GetByAny(name)
+		if !ok || !fi.dbcol {
+			return nil, fmt.Errorf("wrong db field/column name `%s` for model `%s`", name, mi.fullName)
+		}
+		columns = append(columns, fi.column)
+	}
+	return columns, nil
+}
+
 // get fields description as flatted string.
 func getFlatParams(fi *fieldInfo, args []interface{}, tz *time.Location) (params []interface{}) {
 
@@ -175,3 +220,16 @@ outFor:
 	}
 	return
 }
+
+// encode update param of json field, string, []byte and nil are written as it is.
+func getJSONParam(fi *fieldInfo, val interface{}) interface{} {
+	switch val.(type) {
+	case nil, string, []byte, colValue:
+		return val
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		panic(fmt.Errorf("field `%s` cannot be encoded to json, %s", fi.fullName, err))
+	}
+	return string(b)
+}
@@ -0,0 +1,459 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MigrationTable is the bookkeeping table name that records
+// applied migrations, created on demand for every alias.
+var MigrationTable = "orm_migrations"
+
+// MigrationFunc is one direction of a migration.
+type MigrationFunc func(m *Migrator) error
+
+// Migration define a versioned schema change.
+type Migration struct {
+	Version string
+	Name    string
+	Up      MigrationFunc
+	Down    MigrationFunc
+}
+
+// MigrationStatus describe the state of a registered migration on an alias.
+type MigrationStatus struct {
+	Version string
+	Name    string
+	Applied bool
+	At      time.Time
+}
+
+// migration registry.
+type _migrationCache struct {
+	sync.RWMutex
+	cache map[string]*Migration
+}
+
+var migrationCache = &_migrationCache{cache: make(map[string]*Migration)}
+
+// add migration into registry.
+func (mc *_migrationCache) set(m *Migration) {
+	mc.Lock()
+	defer mc.Unlock()
+	if _, ok := mc.cache[m.Version]; ok {
+		panic(fmt.Errorf("<orm.RegisterMigration> migration version `%s` repeat register, must be unique", m.Version))
+	}
+	mc.cache[m.Version] = m
+}
+
+// get migrations ordered by version.
+func (mc *_migrationCache) allOrdered() []*Migration {
+	mc.RLock()
+	defer mc.RUnlock()
+	ms := make([]*Migration, 0, len(mc.cache))
+	for _, m := range mc.cache {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Version < ms[j].Version
+	})
+	return ms
+}
+
+// RegisterMigration register a migration written in go code.
+// version is sortable, e.g. a timestamp like "20190514093000".
+func RegisterMigration(version, name string, up, down MigrationFunc) {
+	if version == "" || up == nil {
+		panic(fmt.Errorf("<orm.RegisterMigration> version and up cannot empty"))
+	}
+	migrationCache.set(&Migration{Version: version, Name: name, Up: up, Down: down})
+}
+
+// RegisterMigrationSQL register a migration made of plain sql statements.
+func RegisterMigrationSQL(version, name string, up, down string) {
+	var downFn MigrationFunc
+	if strings.TrimSpace(down) != "" {
+		downFn = MigrationSQL(down)
+	}
+	RegisterMigration(version, name, MigrationSQL(up), downFn)
+}
+
+// RegisterMigrationDir register all sql migrations in dir.
+// files should be named as <version>_<name>.up.sql and <version>_<name>.down.sql,
+// the down file is optional.
+func RegisterMigrationDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		base := strings.TrimSuffix(filepath.Base(file), ".up.sql")
+		version, name := base, ""
+		if i := strings.Index(base, "_"); i > 0 {
+			version, name = base[:i], base[i+1:]
+		}
+
+		up, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		var down []byte
+		downFile := filepath.Join(dir, base+".down.sql")
+		if _, err := os.Stat(downFile); err == nil {
+			if down, err = ioutil.ReadFile(downFile); err != nil {
+				return err
+			}
+		}
+
+		RegisterMigrationSQL(version, name, string(up), string(down))
+	}
+
+	return nil
+}
+
+// MigrationSQL create a MigrationFunc that executes the sql statements in order,
+// statements are separated by semicolon.
+func MigrationSQL(queries string) MigrationFunc {
+	stmts := splitSQLStatements(queries)
+	return func(m *Migrator) error {
+		for _, query := range stmts {
+			if err := m.Exec(query); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+var dollarQuotePattern = regexp.MustCompile(`^\$\w*\$`)
+
+// split sql script into statements, semicolon inside quotes, comments
+// and postgres dollar-quoted bodies are ignored.
+func splitSQLStatements(script string) (stmts []string) {
+	start := 0
+	add := func(end int) {
+		if s := strings.TrimSpace(script[start:end]); s != "" {
+			stmts = append(stmts, s)
+		}
+	}
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			if j := strings.IndexByte(script[i+1:], c); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(script)
+			}
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if j := strings.IndexByte(script[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if j := strings.Index(script[i+2:], "*/"); j >= 0 {
+				i += j + 3
+			} else {
+				i = len(script)
+			}
+		case c == '$':
+			if tag := dollarQuotePattern.FindString(script[i:]); tag != "" {
+				if j := strings.Index(script[i+len(tag):], tag); j >= 0 {
+					i += len(tag) + j + len(tag) - 1
+				} else {
+					i = len(script)
+				}
+			}
+		case c == ';':
+			add(i)
+			start = i + 1
+		}
+	}
+	if start < len(script) {
+		add(len(script))
+	}
+	return
+}
+
+// Migrator is handed to migration functions,
+// it runs statements inside the migration transaction of an alias.
+// mysql and tidb commit DDL statements implicitly, a failed migration
+// may leave its executed DDL applied there and must be fixed by hand.
+type Migrator struct {
+	al *alias
+	o  Ormer
+}
+
+// Ormer return the ormer used by current migration.
+func (m *Migrator) Ormer() Ormer {
+	return m.o
+}
+
+// Driver return database driver type of current alias.
+func (m *Migrator) Driver() DriverType {
+	return m.al.Driver
+}
+
+// Exec execute a sql statement, `?` marks are replaced per driver.
+func (m *Migrator) Exec(query string, args ...interface{}) error {
+	_, err := m.o.Raw(query, args...).Exec()
+	return err
+}
+
+// HasTable check table exists in database.
+func (m *Migrator) HasTable(table string) bool {
+	tables, err := m.al.DbBaser.GetTables(m.db())
+	return err == nil && tables[table]
+}
+
+// HasColumn check column exists in table.
+func (m *Migrator) HasColumn(table, column string) bool {
+	columns, err := m.al.DbBaser.GetColumns(m.db(), table)
+	if err != nil {
+		return false
+	}
+	_, ok := columns[column]
+	return ok
+}
+
+// HasIndex check index exists in table.
+func (m *Migrator) HasIndex(table, name string) bool {
+	return m.al.DbBaser.IndexExists(m.db(), table, name)
+}
+
+// ColumnType return the database column type for a go type key
+// of the driver type map, e.g. "string", "int64", "time.Time".
+func (m *Migrator) ColumnType(typ string) string {
+	return m.al.DbBaser.DbTypes()[typ]
+}
+
+// get the dbQuerier used by migration ormer.
+func (m *Migrator) db() dbQuerier {
+	return m.o.(*orm).db
+}
+
+// create bookkeeping table if not exists.
+func ensureMigrationTable(al *alias) error {
+	tables, err := al.DbBaser.GetTables(al.DB)
+	if err != nil {
+		return err
+	}
+	if tables[MigrationTable] {
+		return nil
+	}
+
+	Q := al.DbBaser.TableQuote()
+	T := al.DbBaser.DbTypes()
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s%s%s (\n"+
+		"    %sversion%s %s %s,\n"+
+		"    %sname%s %s NOT NULL,\n"+
+		"    %sapplied%s %s NOT NULL\n)",
+		Q, MigrationTable, Q,
+		Q, Q, fmt.Sprintf(T["string"], 100), T["pk"],
+		Q, Q, fmt.Sprintf(T["string"], 255),
+		Q, Q, T["time.Time"])
+
+	_, err = al.DB.Exec(query)
+	return err
+}
+
+// read applied migrations from bookkeeping table.
+func getAppliedMigrations(al *alias) (map[string]time.Time, error) {
+	if err := ensureMigrationTable(al); err != nil {
+		return nil, err
+	}
+
+	o, err := newMigrationOrm(al)
+	if err != nil {
+		return nil, err
+	}
+
+	Q := al.DbBaser.TableQuote()
+	var (
+		versions []string
+		applied  []time.Time
+	)
+	query := fmt.Sprintf("SELECT %sversion%s, %sapplied%s FROM %s%s%s", Q, Q, Q, Q, Q, MigrationTable, Q)
+	if _, err := o.Raw(query).QueryRows(&versions, &applied); err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]time.Time, len(versions))
+	for i, v := range versions {
+		res[v] = applied[i]
+	}
+	return res, nil
+}
+
+// create ormer using given alias.
+func newMigrationOrm(al *alias) (Ormer, error) {
+	o := new(orm)
+	o.alias = al
+	o.interceptors = al.getInterceptors()
+	o.db = wrapDbQuerier(al, o.interceptors, al.DB)
+	return o, nil
+}
+
+// run one direction of a migration inside a transaction and book it,
+// the transaction does not roll back DDL on mysql and tidb.
+func runMigration(al *alias, mg *Migration, up bool) error {
+	fn := mg.Up
+	if !up {
+		fn = mg.Down
+	}
+	if fn == nil {
+		return fmt.Errorf("<Migration> `%s_%s` has no down migration", mg.Version, mg.Name)
+	}
+
+	o, err := newMigrationOrm(al)
+	if err != nil {
+		return err
+	}
+
+	if err := o.Begin(); err != nil {
+		return err
+	}
+
+	Q := al.DbBaser.TableQuote()
+	m := &Migrator{al: al, o: o}
+	if err = fn(m); err == nil {
+		if up {
+			query := fmt.Sprintf("INSERT INTO %s%s%s (%sversion%s, %sname%s, %sapplied%s) VALUES (?, ?, ?)",
+				Q, MigrationTable, Q, Q, Q, Q, Q, Q, Q)
+			err = m.Exec(query, mg.Version, mg.Name, time.Now())
+		} else {
+			query := fmt.Sprintf("DELETE FROM %s%s%s WHERE %sversion%s = ?", Q, MigrationTable, Q, Q, Q)
+			err = m.Exec(query, mg.Version)
+		}
+	}
+
+	if err != nil {
+		o.Rollback()
+		return fmt.Errorf("<Migration> `%s_%s` failed, %s", mg.Version, mg.Name, err)
+	}
+	return o.Commit()
+}
+
+// MigrateStatus return status of all registered migrations on alias.
+func MigrateStatus(name string) ([]MigrationStatus, error) {
+	BootStrap()
+	return migrateStatus(getDbAlias(name), migrationCache.allOrdered())
+}
+
+// MigrateUp apply pending migrations on alias in version order.
+// steps limit the number of migrations to apply, 0 means all.
+func MigrateUp(name string, steps int) (int, error) {
+	BootStrap()
+	return migrateUp(getDbAlias(name), migrationCache.allOrdered(), steps)
+}
+
+// MigrateDown rollback applied migrations on alias from the latest version.
+// steps limit the number of migrations to rollback, 0 means 1.
+func MigrateDown(name string, steps int) (int, error) {
+	BootStrap()
+	reverted, err := migrateDown(getDbAlias(name), migrationCache.allOrdered(), steps)
+	return len(reverted), err
+}
+
+// MigrateRedo rollback the latest migrations and apply them again.
+// steps limit the number of migrations to redo, 0 means 1.
+func MigrateRedo(name string, steps int) error {
+	BootStrap()
+	_, err := migrateRedo(getDbAlias(name), migrationCache.allOrdered(), steps)
+	return err
+}
+
+// get status of migrations ordered by version on alias.
+func migrateStatus(al *alias, ms []*Migration) ([]MigrationStatus, error) {
+	applied, err := getAppliedMigrations(al)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []MigrationStatus
+	for _, mg := range ms {
+		at, ok := applied[mg.Version]
+		res = append(res, MigrationStatus{Version: mg.Version, Name: mg.Name, Applied: ok, At: at})
+	}
+	return res, nil
+}
+
+// apply pending migrations ordered by version on alias.
+func migrateUp(al *alias, ms []*Migration, steps int) (int, error) {
+	applied, err := getAppliedMigrations(al)
+	if err != nil {
+		return 0, err
+	}
+
+	var cnt int
+	for _, mg := range ms {
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+		if steps > 0 && cnt >= steps {
+			break
+		}
+		if err := runMigration(al, mg, true); err != nil {
+			return cnt, err
+		}
+		cnt++
+	}
+	return cnt, nil
+}
+
+// rollback applied migrations ordered by version on alias from the latest one,
+// return the rolled back migrations from the latest one.
+func migrateDown(al *alias, ms []*Migration, steps int) ([]*Migration, error) {
+	applied, err := getAppliedMigrations(al)
+	if err != nil {
+		return nil, err
+	}
+
+	if steps <= 0 {
+		steps = 1
+	}
+
+	var reverted []*Migration
+	for i := len(ms) - 1; i >= 0 && len(reverted) < steps; i-- {
+		mg := ms[i]
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		if err := runMigration(al, mg, false); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, mg)
+	}
+	return reverted, nil
+}
+
+// rollback the latest applied migrations and apply exactly them again,
+// older pending migrations are left pending.
+func migrateRedo(al *alias, ms []*Migration, steps int) (int, error) {
+	reverted, err := migrateDown(al, ms, steps)
+	if err != nil {
+		return 0, err
+	}
+
+	var cnt int
+	for i := len(reverted) - 1; i >= 0; i-- {
+		if err := runMigration(al, reverted[i], true); err != nil {
+			return cnt, err
+		}
+		cnt++
+	}
+	return cnt, nil
+}
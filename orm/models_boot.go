@@ -69,6 +69,7 @@ func registerModel(PrefixOrSuffix string, model interface{}, isPrefix bool) {
 					fi.auto = true
 					fi.pk = true
 					mi.fields.pk = fi
+					mi.fields.pks = []*fieldInfo{fi}
 					break outFor
 				}
 			}
@@ -121,6 +122,10 @@ func bootStrap() {
 					goto end
 				}
 				fi.relModelInfo = mii
+				if fi.rel && len(mii.fields.pks) > 1 {
+					err = fmt.Errorf("field `%s` cannot rel to model `%s` with composite pk", fi.fullName, mii.fullName)
+					goto end
+				}
 
 				switch fi.fieldType {
 				case RelManyToMany:
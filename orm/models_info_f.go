@@ -26,6 +26,7 @@ var errSkipField = errors.New("skip field")
 // field info collection
 type fields struct {
 	pk            *fieldInfo
+	pks           []*fieldInfo // all pk fields, more than one for composite pk
 	columns       map[string]*fieldInfo
 	fields        map[string]*fieldInfo
 	fieldsLow     map[string]*fieldInfo
@@ -34,6 +35,8 @@ type fields struct {
 	fieldsReverse []*fieldInfo
 	fieldsDB      []*fieldInfo
 	rels          []*fieldInfo
+	softDelete    *fieldInfo
+	version       *fieldInfo
 	orders        []string
 	dbcols        []string
 }
@@ -122,6 +125,8 @@ type fieldInfo struct {
 	toText              bool
 	autoNow             bool
 	autoNowAdd          bool
+	softDelete          bool // set deleted time instead of delete row
+	version             bool // optimistic locking version
 	rel                 bool // if type equal to RelForeignKey, RelOneToOne, RelManyToMany then true
 	reverse             bool
 	reverseField        string
@@ -134,7 +139,9 @@ type fieldInfo struct {
 	relModelInfo        *modelInfo
 	digits              int
 	decimals            int
-	isFielder           bool // implement Fielder interface
+	isFielder           bool   // implement Fielder interface
+	jsonValue           bool   // struct, map or slice encoded as json
+	uuid                string // version of uuid generated for empty pk on insert, v4 or v7
 	onDelete            string
 	description         string
 }
@@ -241,6 +248,15 @@ checkType:
 			}
 		}
 
+		if t := tags["type"]; (t == "json" || t == "jsonb") && isJSONValue(field.Type()) {
+			fieldType = TypeJSONField
+			if t == "jsonb" {
+				fieldType = TypeJsonbField
+			}
+			fi.jsonValue = true
+			break checkType
+		}
+
 		fieldType, err = getFieldType(addrField)
 		if err != nil {
 			goto end
@@ -255,6 +271,12 @@ checkType:
 				fieldType = TypeJSONField
 			case "jsonb":
 				fieldType = TypeJsonbField
+			case "uuid":
+				fieldType = TypeCharField
+				fi.uuid = "v4"
+			case "uuid7":
+				fieldType = TypeCharField
+				fi.uuid = "v7"
 			}
 		}
 		if fieldType == TypeFloatField && (digits != "" || decimals != "") {
@@ -362,7 +384,9 @@ checkType:
 	switch fieldType {
 	case TypeBooleanField:
 	case TypeVarCharField, TypeCharField, TypeJSONField, TypeJsonbField:
-		if size != "" {
+		if fi.uuid != "" {
+			fi.size = 36
+		} else if size != "" {
 			v, e := StrTo(size).Int32()
 			if e != nil {
 				err = fmt.Errorf("wrong size value `%s`", size)
@@ -382,6 +406,10 @@ checkType:
 		} else if attrs["auto_now_add"] {
 			fi.autoNowAdd = true
 		}
+		if attrs["soft_delete"] && fieldType != TypeTimeField {
+			fi.softDelete = true
+			fi.null = true
+		}
 	case TypeFloatField:
 	case TypeDecimalField:
 		d1 := digits
@@ -397,10 +425,23 @@ checkType:
 	default:
 		switch {
 		case fieldType&IsIntegerField > 0:
+			if attrs["version"] && !fi.isFielder && field.Kind() != reflect.Ptr {
+				fi.version = true
+			}
 		case fieldType&IsRelField > 0:
 		}
 	}
 
+	if attrs["version"] && (!fi.version || fi.pk || fi.auto) {
+		err = fmt.Errorf("version only support non-pk integer field")
+		goto end
+	}
+
+	if attrs["soft_delete"] && !fi.softDelete {
+		err = fmt.Errorf("soft_delete only support date/datetime field")
+		goto end
+	}
+
 	if fieldType&IsIntegerField == 0 {
 		if fi.auto {
 			err = fmt.Errorf("non-integer type cannot set auto")
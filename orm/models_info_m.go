@@ -84,11 +84,29 @@ func addModelFields(mi *modelInfo, ind reflect.Value, mName string, index []int)
 			break
 		}
 		if fi.pk {
-			if mi.fields.pk != nil {
-				err = fmt.Errorf("one model must have one pk field only")
+			if mi.fields.pk == nil {
+				mi.fields.pk = fi
+			}
+			mi.fields.pks = append(mi.fields.pks, fi)
+			if len(mi.fields.pks) > 1 && (mi.fields.pk.auto || fi.auto) {
+				err = fmt.Errorf("auto field cannot be part of composite pk")
+				break
+			}
+		}
+		if fi.softDelete {
+			if mi.fields.softDelete != nil {
+				err = fmt.Errorf("one model must have one soft_delete field only")
 				break
 			} else {
-				mi.fields.pk = fi
+				mi.fields.softDelete = fi
+			}
+		}
+		if fi.version {
+			if mi.fields.version != nil {
+				err = fmt.Errorf("one model must have one version field only")
+				break
+			} else {
+				mi.fields.version = fi
 			}
 		}
 	}
@@ -142,6 +160,7 @@ func newM2MModelInfo(m1, m2 *modelInfo) (mi *modelInfo) {
 	mi.fields.Add(f1)
 	mi.fields.Add(f2)
 	mi.fields.pk = fa
+	mi.fields.pks = []*fieldInfo{fa}
 
 	mi.uniques = []string{f1.column, f2.column}
 	return
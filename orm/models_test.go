@@ -17,6 +17,7 @@ package orm
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -409,6 +410,104 @@ type PtrPk struct {
 	Positive bool
 }
 
+type Hook struct {
+	ID      int
+	Name    string `orm:"size(30)"`
+	Slug    string `orm:"size(30)"`
+	Updated int
+	events  []string
+}
+
+func (h *Hook) BeforeInsert(o Ormer) error {
+	if h.Name == "" {
+		return errors.New("name is required")
+	}
+	h.Slug = strings.ToLower(h.Name)
+	h.events = append(h.events, "BeforeInsert")
+	return nil
+}
+
+func (h *Hook) AfterInsert(o Ormer) error {
+	h.events = append(h.events, "AfterInsert")
+	return nil
+}
+
+func (h *Hook) BeforeUpdate(o Ormer) error {
+	h.Updated++
+	h.events = append(h.events, "BeforeUpdate")
+	return nil
+}
+
+func (h *Hook) AfterUpdate(o Ormer) error {
+	h.events = append(h.events, "AfterUpdate")
+	return nil
+}
+
+func (h *Hook) BeforeDelete(o Ormer) error {
+	h.events = append(h.events, "BeforeDelete")
+	return nil
+}
+
+func (h *Hook) AfterDelete(o Ormer) error {
+	h.events = append(h.events, "AfterDelete")
+	return nil
+}
+
+func (h *Hook) AfterRead(o Ormer) error {
+	h.events = append(h.events, "AfterRead")
+	return nil
+}
+
+type Trash struct {
+	ID        int
+	Name      string    `orm:"size(30)"`
+	DeletedAt time.Time `orm:"soft_delete"`
+}
+
+type Versioned struct {
+	ID      int
+	Name    string `orm:"size(30)"`
+	Version int    `orm:"version"`
+}
+
+type Tracked struct {
+	Tracker
+	ID      int
+	Name    string `orm:"size(30)"`
+	Status  int
+	Updated time.Time `orm:"auto_now;type(datetime);null"`
+}
+
+type DocumentSettings struct {
+	Theme string `json:"theme"`
+	Size  int    `json:"size"`
+}
+
+type DocumentMeta struct {
+	Settings DocumentSettings `json:"settings"`
+	Labels   []string         `json:"labels"`
+}
+
+type Document struct {
+	ID     int
+	Title  string            `orm:"size(30)"`
+	Meta   DocumentMeta      `orm:"type(jsonb);null"`
+	Attrs  map[string]string `orm:"type(json);null"`
+	Tags   []string          `orm:"type(json);null"`
+	Layout *DocumentSettings `orm:"type(json);null"`
+}
+
+type Account struct {
+	ID   string `orm:"pk;type(uuid7)"`
+	Name string `orm:"size(30)"`
+}
+
+type AccountMember struct {
+	Account *Account `orm:"pk;rel(fk)"`
+	User    *User    `orm:"pk;rel(fk)"`
+	Role    string   `orm:"size(30)"`
+}
+
 var DBARGS = struct {
 	Driver string
 	Source string
@@ -33,6 +33,8 @@ var supportTag = map[string]int{
 	"auto":         1,
 	"auto_now":     1,
 	"auto_now_add": 1,
+	"soft_delete":  1,
+	"version":      1,
 	"size":         2,
 	"column":       2,
 	"default":      2,
@@ -202,6 +204,24 @@ func getFieldType(val reflect.Value) (ft int, err error) {
 	return
 }
 
+// check type of field is encoded as json by type(json) or type(jsonb), such as struct, map and slice.
+func isJSONValue(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	case reflect.Struct:
+		switch reflect.New(typ).Interface().(type) {
+		case *time.Time, *sql.NullString, *sql.NullInt64, *sql.NullFloat64, *sql.NullBool:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
 // parse struct tag string
 func parseStructTag(data string) (attrs map[string]bool, tags map[string]string) {
 	attrs = make(map[string]bool)
@@ -81,6 +81,7 @@ var (
 	ErrStmtClosed    = errors.New("<QuerySeter> stmt already closed")
 	ErrArgs          = errors.New("<Ormer> args error may be empty")
 	ErrNotImplement  = errors.New("have not implement")
+	ErrStaleObject   = errors.New("<Ormer.Update> object has been modified or deleted")
 )
 
 // Params stores the Params
@@ -90,9 +91,15 @@ type Params map[string]interface{}
 type ParamsList []interface{}
 
 type orm struct {
-	alias *alias
-	db    dbQuerier
-	isTx  bool
+	alias    *alias
+	db       dbQuerier
+	isTx     bool
+	ctx      context.Context
+	primary  bool
+	nested   int
+	txTables map[string]bool // tables written in transaction
+	// interceptors of alias when ormer is created
+	interceptors []QueryInterceptor
 }
 
 var _ Ormer = new(orm)
@@ -124,13 +131,21 @@ func (o *orm) getFieldInfo(mi *modelInfo, name string) *fieldInfo {
 // read data to model
 func (o *orm) Read(md interface{}, cols ...string) error {
 	mi, ind := o.getMiInd(md, true)
-	return o.alias.DbBaser.Read(o.db, mi, ind, o.alias.TZ, cols, false)
+	if err := o.alias.DbBaser.Read(o.db, mi, ind, o.alias.TZ, cols, false); err != nil {
+		return err
+	}
+	snapshotModel(o.alias.DbBaser, mi, ind, o.alias.TZ, nil)
+	return callHook(o, md, hookAfterRead)
 }
 
 // read data to model, like Read(), but use "SELECT FOR UPDATE" form
 func (o *orm) ReadForUpdate(md interface{}, cols ...string) error {
 	mi, ind := o.getMiInd(md, true)
-	return o.alias.DbBaser.Read(o.db, mi, ind, o.alias.TZ, cols, true)
+	if err := o.alias.DbBaser.Read(o.db, mi, ind, o.alias.TZ, cols, true); err != nil {
+		return err
+	}
+	snapshotModel(o.alias.DbBaser, mi, ind, o.alias.TZ, nil)
+	return callHook(o, md, hookAfterRead)
 }
 
 // Try to read a row from the database, or insert one if it doesn't exist
@@ -142,6 +157,9 @@ func (o *orm) ReadOrCreate(md interface{}, col1 string, cols ...string) (bool, i
 		// Create
 		id, err := o.Insert(md)
 		return (err == nil), id, err
+	} else if err == nil {
+		snapshotModel(o.alias.DbBaser, mi, ind, o.alias.TZ, nil)
+		err = callHook(o, md, hookAfterRead)
 	}
 
 	id, vid := int64(0), ind.FieldByIndex(mi.fields.pk.fieldIndex)
@@ -149,7 +167,7 @@ func (o *orm) ReadOrCreate(md interface{}, col1 string, cols ...string) (bool, i
 		id = int64(vid.Uint())
 	} else if mi.fields.pk.rel {
 		return o.ReadOrCreate(vid.Interface(), mi.fields.pk.relModelInfo.fields.pk.name)
-	} else {
+	} else if mi.fields.pk.fieldType&IsIntegerField > 0 {
 		id = vid.Int()
 	}
 
@@ -159,14 +177,19 @@ func (o *orm) ReadOrCreate(md interface{}, col1 string, cols ...string) (bool, i
 // insert model data to database
 func (o *orm) Insert(md interface{}) (int64, error) {
 	mi, ind := o.getMiInd(md, true)
+	if err := callHook(o, md, hookBeforeInsert); err != nil {
+		return 0, err
+	}
 	id, err := o.alias.DbBaser.Insert(o.db, mi, ind, o.alias.TZ)
 	if err != nil {
 		return id, err
 	}
 
 	o.setPk(mi, ind, id)
+	snapshotModel(o.alias.DbBaser, mi, ind, o.alias.TZ, nil)
+	o.invalidate(mi.table)
 
-	return id, nil
+	return id, callHook(o, md, hookAfterInsert)
 }
 
 // set auto pk field
@@ -199,18 +222,47 @@ func (o *orm) InsertMulti(bulk int, mds interface{}) (int64, error) {
 		for i := 0; i < sind.Len(); i++ {
 			ind := reflect.Indirect(sind.Index(i))
 			mi, _ := o.getMiInd(ind.Interface(), false)
+			md := hookModel(ind)
+			if err := callHook(o, md, hookBeforeInsert); err != nil {
+				return cnt, err
+			}
 			id, err := o.alias.DbBaser.Insert(o.db, mi, ind, o.alias.TZ)
 			if err != nil {
 				return cnt, err
 			}
 
 			o.setPk(mi, ind, id)
+			o.invalidate(mi.table)
 
 			cnt++
+
+			if err := callHook(o, md, hookAfterInsert); err != nil {
+				return cnt, err
+			}
 		}
 	} else {
+		for i := 0; i < sind.Len(); i++ {
+			md := hookModel(reflect.Indirect(sind.Index(i)))
+			if err := callHook(o, md, hookBeforeInsert); err != nil {
+				return cnt, err
+			}
+		}
+
 		mi, _ := o.getMiInd(sind.Index(0).Interface(), false)
-		return o.alias.DbBaser.InsertMulti(o.db, mi, sind, bulk, o.alias.TZ)
+		num, err := o.alias.DbBaser.InsertMulti(o.db, mi, sind, bulk, o.alias.TZ)
+		if err != nil {
+			return num, err
+		}
+		o.invalidate(mi.table)
+
+		// pk is not set by bulk insert
+		for i := 0; i < sind.Len(); i++ {
+			md := hookModel(reflect.Indirect(sind.Index(i)))
+			if err := callHook(o, md, hookAfterInsert); err != nil {
+				return num, err
+			}
+		}
+		cnt = num
 	}
 	return cnt, nil
 }
@@ -222,31 +274,104 @@ func (o *orm) InsertOrUpdate(md interface{}, colConflitAndArgs ...string) (int64
 	if err != nil {
 		return id, err
 	}
+	o.invalidate(mi.table)
 
 	o.setPk(mi, ind, id)
 
 	return id, nil
 }
 
+// insert some models to database, update cols of rows conflict on conflicts columns
+func (o *orm) InsertOrUpdateMulti(bulk int, mds interface{}, conflicts []string, cols ...string) (int64, error) {
+	sind := reflect.Indirect(reflect.ValueOf(mds))
+
+	switch sind.Kind() {
+	case reflect.Array, reflect.Slice:
+		if sind.Len() == 0 {
+			return 0, ErrArgs
+		}
+	default:
+		return 0, ErrArgs
+	}
+
+	mi, _ := o.getMiInd(sind.Index(0).Interface(), false)
+	num, err := o.alias.DbBaser.InsertOrUpdateMulti(o.db, mi, sind, bulk, conflicts, cols, o.alias.TZ)
+	if err == nil {
+		o.invalidate(mi.table)
+	}
+	return num, err
+}
+
 // update model to database.
 // cols set the columns those want to update.
 func (o *orm) Update(md interface{}, cols ...string) (int64, error) {
 	mi, ind := o.getMiInd(md, true)
-	return o.alias.DbBaser.Update(o.db, mi, ind, o.alias.TZ, cols)
+	if err := callHook(o, md, hookBeforeUpdate); err != nil {
+		return 0, err
+	}
+	// tracked model write the changed fields only
+	if len(cols) == 0 {
+		if changes, ok := modelChanges(o.alias.DbBaser, mi, ind, o.alias.TZ); ok {
+			if len(changes) == 0 {
+				return 0, callHook(o, md, hookAfterUpdate)
+			}
+			cols = changedCols(mi, changes)
+		}
+	}
+	num, err := o.alias.DbBaser.Update(o.db, mi, ind, o.alias.TZ, cols)
+	if err != nil {
+		return num, err
+	}
+	snapshotModel(o.alias.DbBaser, mi, ind, o.alias.TZ, cols)
+	o.invalidate(mi.table)
+	return num, callHook(o, md, hookAfterUpdate)
+}
+
+// get changed fields of model embed Tracker since it is loaded or saved.
+func (o *orm) Changes(md interface{}) []FieldChange {
+	mi, ind := o.getMiInd(md, true)
+	changes, _ := modelChanges(o.alias.DbBaser, mi, ind, o.alias.TZ)
+	return changes
 }
 
 // delete model in database
 // cols shows the delete conditions values read from. default is pk
 func (o *orm) Delete(md interface{}, cols ...string) (int64, error) {
 	mi, ind := o.getMiInd(md, true)
+	if mi.fields.softDelete == nil {
+		return o.delete(md, mi, ind, cols)
+	}
+	if err := callHook(o, md, hookBeforeDelete); err != nil {
+		return 0, err
+	}
+	num, err := o.alias.DbBaser.SoftDelete(o.db, mi, ind, o.alias.TZ, cols)
+	if err != nil {
+		return num, err
+	}
+	o.invalidate(mi.table)
+	return num, callHook(o, md, hookAfterDelete)
+}
+
+// delete model in database permanently, soft_delete field is ignored.
+func (o *orm) ForceDelete(md interface{}, cols ...string) (int64, error) {
+	mi, ind := o.getMiInd(md, true)
+	return o.delete(md, mi, ind, cols)
+}
+
+// execute delete sql and reset pk of model.
+func (o *orm) delete(md interface{}, mi *modelInfo, ind reflect.Value, cols []string) (int64, error) {
+	if err := callHook(o, md, hookBeforeDelete); err != nil {
+		return 0, err
+	}
 	num, err := o.alias.DbBaser.Delete(o.db, mi, ind, o.alias.TZ, cols)
 	if err != nil {
 		return num, err
 	}
+	o.invalidate(deleteTables(mi)...)
 	if num > 0 {
 		o.setPk(mi, ind, 0)
 	}
-	return num, nil
+	return num, callHook(o, md, hookAfterDelete)
 }
 
 // create a models to models queryer
@@ -447,10 +572,10 @@ func (o *orm) Using(name string) error {
 	}
 	if al, ok := dataBaseCache.get(name); ok {
 		o.alias = al
-		if Debug {
-			o.db = newDbQueryLog(al, al.DB)
-		} else {
-			o.db = al.DB
+		o.interceptors = al.getInterceptors()
+		o.db = wrapDbQuerier(al, o.interceptors, al.DB)
+		if o.ctx != nil {
+			o.db = newDbQueryCtx(o.ctx, o.db)
 		}
 	} else {
 		return fmt.Errorf("<Ormer.Using> unknown db alias name `%s`", name)
@@ -460,6 +585,9 @@ func (o *orm) Using(name string) error {
 
 // begin transaction
 func (o *orm) Begin() error {
+	if o.ctx != nil {
+		return o.BeginTx(o.ctx, nil)
+	}
 	return o.BeginTx(context.Background(), nil)
 }
 
@@ -473,8 +601,8 @@ func (o *orm) BeginTx(ctx context.Context, opts *sql.TxOptions) error {
 		return err
 	}
 	o.isTx = true
-	if Debug {
-		o.db.(*dbQueryLog).SetDB(tx)
+	if s, ok := o.db.(dbSetter); ok {
+		s.SetDB(tx)
 	} else {
 		o.db = tx
 	}
@@ -489,6 +617,7 @@ func (o *orm) Commit() error {
 	err := o.db.(txEnder).Commit()
 	if err == nil {
 		o.isTx = false
+		o.invalidateTx()
 		o.Using(o.alias.Name)
 	} else if err == sql.ErrTxDone {
 		return ErrTxDone
@@ -504,6 +633,7 @@ func (o *orm) Rollback() error {
 	err := o.db.(txEnder).Rollback()
 	if err == nil {
 		o.isTx = false
+		o.txTables = nil
 		o.Using(o.alias.Name)
 	} else if err == sql.ErrTxDone {
 		return ErrTxDone
@@ -511,6 +641,108 @@ func (o *orm) Rollback() error {
 	return err
 }
 
+// run fn in transaction.
+func (o *orm) Transaction(fn func(o Ormer) error) error {
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return o.TransactionTx(ctx, nil, fn)
+}
+
+// run fn in transaction with provided context and option.
+// the transaction is committed when fn return nil, rolled back when fn
+// return error or panic. savepoint is used when transaction has began.
+func (o *orm) TransactionTx(ctx context.Context, opts *sql.TxOptions, fn func(o Ormer) error) (err error) {
+	if o.isTx {
+		return o.savepoint(fn)
+	}
+
+	if err = o.BeginTx(ctx, opts); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			o.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err = fn(o); err != nil {
+		o.Rollback()
+		return err
+	}
+	return o.Commit()
+}
+
+// run fn inside a savepoint of current transaction.
+func (o *orm) savepoint(fn func(o Ormer) error) (err error) {
+	if !o.alias.DbBaser.SupportSavepoint() {
+		return ErrTxHasBegan
+	}
+
+	o.nested++
+	name := fmt.Sprintf("orm_savepoint_%d", o.nested)
+	defer func() {
+		o.nested--
+	}()
+
+	if _, err = o.db.Exec("SAVEPOINT " + name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			o.db.Exec("ROLLBACK TO SAVEPOINT " + name)
+			panic(r)
+		}
+	}()
+
+	if err = fn(o); err != nil {
+		o.db.Exec("ROLLBACK TO SAVEPOINT " + name)
+		return err
+	}
+	_, err = o.db.Exec("RELEASE SAVEPOINT " + name)
+	return err
+}
+
+// return a copy of ormer bound to context.
+func (o *orm) WithContext(ctx context.Context) Ormer {
+	if ctx == nil {
+		panic(fmt.Errorf("<Ormer.WithContext> context cannot be nil"))
+	}
+	n := *o
+	n.ctx = ctx
+	n.db = newDbQueryCtx(ctx, cloneDbQuerier(o.db))
+	return &n
+}
+
+// return a copy of ormer that reads from primary database only.
+func (o *orm) ForcePrimary() Ormer {
+	n := *o
+	n.primary = true
+	n.db = cloneDbQuerier(o.db)
+	return &n
+}
+
+// get dbQuerier for read query, a replica is chosen when available.
+func (o *orm) readDB() dbQuerier {
+	if o.isTx || o.primary {
+		return o.db
+	}
+	al := o.alias.replica()
+	if al == nil {
+		return o.db
+	}
+
+	db := wrapDbQuerier(al, o.interceptors, al.DB)
+	if o.ctx != nil {
+		db = newDbQueryCtx(o.ctx, db)
+	}
+	return db
+}
+
 // return a raw query seter for raw sql string.
 func (o *orm) Raw(query string, args ...interface{}) RawSeter {
 	return newRawSet(o, query, args)
@@ -531,6 +763,22 @@ func (o *orm) DBStats() *sql.DBStats {
 	return nil
 }
 
+// HasPk check all pk fields of model are not empty,
+// model without pk is not inserted yet unless its pk is set manually.
+// false is returned when model is not registered or models are not bootstrapped.
+func HasPk(md interface{}) bool {
+	if !modelCache.done {
+		return false
+	}
+	ind := reflect.Indirect(reflect.ValueOf(md))
+	mi, ok := modelCache.getByFullName(getFullName(ind.Type()))
+	if !ok {
+		return false
+	}
+	_, _, exist := getExistPks(mi, ind)
+	return exist
+}
+
 // NewOrm create new orm
 func NewOrm() Ormer {
 	BootStrap() // execute only once
@@ -564,11 +812,7 @@ func NewOrmWithDB(driverName, aliasName string, db *sql.DB) (Ormer, error) {
 	o := new(orm)
 	o.alias = al
 
-	if Debug {
-		o.db = newDbQueryLog(o.alias, db)
-	} else {
-		o.db = db
-	}
+	o.db = wrapDbQuerier(o.alias, nil, db)
 
 	return o, nil
 }
@@ -0,0 +1,182 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Aggregation is an aggregate function over model field, used by QuerySeter.Annotate.
+type Aggregation struct {
+	fn       string
+	expr     string
+	alias    string
+	distinct bool
+}
+
+// Sum create SUM aggregation of field expression, e.g. Sum("amount").
+func Sum(expr string) *Aggregation {
+	return &Aggregation{fn: "SUM", expr: expr}
+}
+
+// Avg create AVG aggregation of field expression, e.g. Avg("profile__age").
+func Avg(expr string) *Aggregation {
+	return &Aggregation{fn: "AVG", expr: expr}
+}
+
+// Min create MIN aggregation of field expression.
+func Min(expr string) *Aggregation {
+	return &Aggregation{fn: "MIN", expr: expr}
+}
+
+// Max create MAX aggregation of field expression.
+func Max(expr string) *Aggregation {
+	return &Aggregation{fn: "MAX", expr: expr}
+}
+
+// Count create COUNT aggregation of field expression, "*" count rows.
+func Count(expr string) *Aggregation {
+	return &Aggregation{fn: "COUNT", expr: expr}
+}
+
+// As set the result name of aggregation,
+// default is expression and function name joined by "__", e.g. "amount__sum".
+func (a *Aggregation) As(alias string) *Aggregation {
+	a.alias = alias
+	return a
+}
+
+// Distinct aggregate distinct values only.
+func (a *Aggregation) Distinct() *Aggregation {
+	a.distinct = true
+	return a
+}
+
+// get result name of aggregation.
+func (a *Aggregation) name() string {
+	if a.alias != "" {
+		return a.alias
+	}
+	expr := a.expr
+	if expr == "*" {
+		expr = "all"
+	}
+	return expr + ExprSep + strings.ToLower(a.fn)
+}
+
+// annotated aggregation resolved to sql.
+type annotation struct {
+	sql string
+	fi  *fieldInfo
+}
+
+// resolve aggregation into sql expression and the field info of result.
+func (t *dbTables) getAnnotation(a *Aggregation) *annotation {
+	distinct := ""
+	if a.distinct {
+		distinct = "DISTINCT "
+	}
+
+	if a.expr == "*" {
+		return &annotation{
+			sql: fmt.Sprintf("%s(%s*)", a.fn, distinct),
+			fi:  &fieldInfo{fieldType: TypeBigIntegerField},
+		}
+	}
+
+	index, _, fi, suc := t.parseExprs(t.mi, strings.Split(a.expr, ExprSep))
+	if !suc {
+		panic(fmt.Errorf("unknown field/column name `%s`", a.expr))
+	}
+
+	Q := t.base.TableQuote()
+	ann := &annotation{sql: fmt.Sprintf("%s(%s%s.%s%s%s)", a.fn, distinct, index, Q, fi.column, Q), fi: fi}
+	switch a.fn {
+	case "COUNT":
+		ann.fi = &fieldInfo{fieldType: TypeBigIntegerField}
+	case "AVG":
+		ann.fi = &fieldInfo{fieldType: TypeFloatField}
+	}
+	return ann
+}
+
+// generate aggregate sql of query set,
+// exprs are the grouped field expressions to select, default is GroupBy fields.
+func getAggregateSQL(d dbBaser, qs *querySet, cond *Condition, exprs []string, tz *time.Location) (string, []interface{}) {
+	if len(qs.annotations) == 0 {
+		panic(fmt.Errorf("<QuerySeter.Aggregate> annotations cannot empty"))
+	}
+	if len(exprs) == 0 {
+		exprs = qs.groups
+	}
+
+	Q := d.TableQuote()
+	mi := qs.mi
+
+	tables := newDbTables(mi, d)
+	tables.trashed = qs.trashed
+	tables.annotations = make(map[string]*annotation, len(qs.annotations))
+
+	sels := make([]string, 0, len(exprs)+len(qs.annotations))
+	for _, expr := range exprs {
+		index, _, fi, suc := tables.parseExprs(mi, strings.Split(expr, ExprSep))
+		if !suc {
+			panic(fmt.Errorf("unknown field/column name `%s`", expr))
+		}
+		sels = append(sels, fmt.Sprintf("%s.%s%s%s %s%s%s", index, Q, fi.column, Q, Q, expr, Q))
+	}
+
+	for _, a := range qs.annotations {
+		ann := tables.getAnnotation(a)
+		name := a.name()
+		tables.annotations[name] = ann
+		sels = append(sels, fmt.Sprintf("%s %s%s%s", ann.sql, Q, name, Q))
+	}
+
+	where, args := tables.getCondSQL(cond, false, tz)
+	groupBy := tables.getGroupSQL(qs.groups)
+
+	having := ""
+	if qs.having != nil && !qs.having.IsEmpty() {
+		h, hargs := tables.getCondSQL(qs.having, true, tz)
+		having = "HAVING " + h
+		args = append(args, hargs...)
+	}
+
+	// order by grouped field or annotation
+	orderBy := ""
+	if len(qs.orders) > 0 {
+		orders := make([]string, 0, len(qs.orders))
+		for _, order := range qs.orders {
+			asc := "ASC"
+			if order[0] == '-' {
+				asc = "DESC"
+				order = order[1:]
+			}
+			if _, ok := tables.annotations[order]; ok {
+				orders = append(orders, fmt.Sprintf("%s%s%s %s", Q, order, Q, asc))
+				continue
+			}
+			index, _, fi, suc := tables.parseExprs(mi, strings.Split(order, ExprSep))
+			if !suc {
+				panic(fmt.Errorf("unknown field/column name `%s`", order))
+			}
+			orders = append(orders, fmt.Sprintf("%s.%s%s%s %s", index, Q, fi.column, Q, asc))
+		}
+		orderBy = fmt.Sprintf("ORDER BY %s ", strings.Join(orders, ", "))
+	}
+
+	limit := ""
+	if qs.limit != 0 || qs.offset != 0 {
+		limit = tables.getLimitSQL(mi, qs.offset, qs.limit)
+	}
+	join := tables.getJoinSQL()
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s%s T0 %s%s%s%s%s%s", strings.Join(sels, ", "),
+		Q, mi.table, Q, join, where, groupBy, having, orderBy, limit)
+	return query, args
+}
@@ -0,0 +1,349 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/raryanda/go/cache"
+	"go.uber.org/zap"
+)
+
+// QueryCache stores results of query set and raw set cached by Cache,
+// nil by default which disables query cache and invalidation of written tables,
+// set it to cache.Instance to cache results in the configured cache.
+var QueryCache cache.Cache
+
+func init() {
+	// driver values stored in interface of cached rows
+	gob.Register(time.Time{})
+}
+
+// rows of query result, read from database or cache.
+type resultRows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(dest ...interface{}) error
+	Close() error
+	Err() error
+}
+
+// queryCache is the cache option of query set or raw set.
+// results are stored in QueryCache, keyed by the sql, args and generation of tables.
+type queryCache struct {
+	alias  string
+	ttl    time.Duration
+	tables []string
+}
+
+// cachedResult is the rows of query stored in cache.
+type cachedResult struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// get key of table generation, the generation is changed when table is written.
+func tableCacheKey(alias, table string) string {
+	return fmt.Sprintf("orm:%s:table:%s", alias, table)
+}
+
+// invalidate cached results of tables by changing their generations.
+func invalidateTables(alias string, tables ...string) {
+	if QueryCache == nil {
+		return
+	}
+	gen := time.Now().UnixNano()
+	for _, table := range tables {
+		if err := QueryCache.Set(tableCacheKey(alias, table), gen, cache.ForEverNeverExpiry); err != nil {
+			// cached results of table are stale until their ttl is expired
+			DebugLog.Warn("ORM/CACHE", zap.String("table", table), zap.Error(err))
+		}
+	}
+}
+
+// get cache key of query, empty when generation of tables is unavailable.
+func (c *queryCache) key(query string, args []interface{}) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\x00%v", query, args)
+	for _, table := range c.tables {
+		key := tableCacheKey(c.alias, table)
+		var gen int64
+		if err := QueryCache.Get(key, &gen); err != nil {
+			if err != cache.ErrCacheMiss {
+				return ""
+			}
+			// results cached before the generation is lost are unreachable
+			gen = time.Now().UnixNano()
+			if err := QueryCache.Set(key, gen, cache.ForEverNeverExpiry); err != nil {
+				return ""
+			}
+		}
+		fmt.Fprintf(h, "\x00%s:%d", table, gen)
+	}
+	return fmt.Sprintf("orm:%s:query:%s", c.alias, hex.EncodeToString(h.Sum(nil)))
+}
+
+// query rows of database with context, nil context is not used.
+func queryContext(ctx context.Context, q dbQuerier, query string, args []interface{}) (*sql.Rows, error) {
+	if ctx != nil {
+		return q.QueryContext(ctx, query, args...)
+	}
+	return q.Query(query, args...)
+}
+
+// query rows from cache, or from database and store them into cache when all rows are read.
+// nil cache query database only.
+func queryCached(ctx context.Context, q dbQuerier, c *queryCache, query string, args []interface{}) (resultRows, error) {
+	if c == nil || QueryCache == nil {
+		rs, err := queryContext(ctx, q, query, args)
+		if err != nil {
+			return nil, err
+		}
+		return rs, nil
+	}
+
+	key := c.key(query, args)
+	if key != "" {
+		var res cachedResult
+		if err := QueryCache.Get(key, &res); err == nil {
+			return &cachedRows{result: res, index: -1}, nil
+		}
+	}
+
+	rs, err := queryContext(ctx, q, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return rs, nil
+	}
+	cols, err := rs.Columns()
+	if err != nil {
+		rs.Close()
+		return nil, err
+	}
+	return &recordRows{Rows: rs, key: key, ttl: c.ttl, result: cachedResult{Columns: cols}}, nil
+}
+
+// query one row and scan it into dest, nil cache query database only.
+func queryRowCached(ctx context.Context, q dbQuerier, c *queryCache, query string, args []interface{}, dest ...interface{}) error {
+	if c == nil || QueryCache == nil {
+		if ctx != nil {
+			return q.QueryRowContext(ctx, query, args...).Scan(dest...)
+		}
+		return q.QueryRow(query, args...).Scan(dest...)
+	}
+
+	rs, err := queryCached(ctx, q, c, query, args)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+
+	if !rs.Next() {
+		if err := rs.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := rs.Scan(dest...); err != nil {
+		return err
+	}
+	// read to the end so the row is cached
+	for rs.Next() {
+	}
+	return rs.Err()
+}
+
+// rows of database recorded into cache when closed after all rows are read.
+type recordRows struct {
+	*sql.Rows
+	key    string
+	ttl    time.Duration
+	result cachedResult
+	done   bool
+}
+
+// prepare the next row, done when there is no more row.
+func (r *recordRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.done = r.Rows.Err() == nil
+	return false
+}
+
+// scan the row into dest and record the values.
+func (r *recordRows) Scan(dest ...interface{}) error {
+	if err := r.Rows.Scan(dest...); err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(dest))
+	for i, d := range dest {
+		switch v := d.(type) {
+		case *interface{}:
+			row[i] = *v
+		case sqldriver.Valuer:
+			row[i], _ = v.Value()
+		default:
+			row[i] = reflect.Indirect(reflect.ValueOf(d)).Interface()
+		}
+	}
+	r.result.Rows = append(r.result.Rows, row)
+	return nil
+}
+
+// close rows and store the recorded result.
+func (r *recordRows) Close() error {
+	err := r.Rows.Close()
+	if err == nil && r.done {
+		if err := QueryCache.Set(r.key, r.result, r.ttl); err != nil {
+			DebugLog.Warn("ORM/CACHE", zap.String("key", r.key), zap.Error(err))
+		}
+	}
+	return err
+}
+
+// rows read from cache.
+type cachedRows struct {
+	result cachedResult
+	index  int
+}
+
+// get columns of rows.
+func (r *cachedRows) Columns() ([]string, error) {
+	return r.result.Columns, nil
+}
+
+// prepare the next row.
+func (r *cachedRows) Next() bool {
+	r.index++
+	return r.index < len(r.result.Rows)
+}
+
+// scan values of current row into dest.
+func (r *cachedRows) Scan(dest ...interface{}) error {
+	row := r.result.Rows[r.index]
+	if len(dest) != len(row) {
+		return fmt.Errorf("cached rows expected %d destination arguments in Scan, not %d", len(row), len(dest))
+	}
+
+	for i, d := range dest {
+		switch v := d.(type) {
+		case *interface{}:
+			*v = row[i]
+		case sql.Scanner:
+			if err := v.Scan(row[i]); err != nil {
+				return err
+			}
+		default:
+			ind := reflect.Indirect(reflect.ValueOf(d))
+			if row[i] == nil {
+				ind.Set(reflect.Zero(ind.Type()))
+				continue
+			}
+			val := reflect.ValueOf(row[i])
+			if !val.Type().ConvertibleTo(ind.Type()) {
+				return fmt.Errorf("cached value type %s cannot scan into %T", val.Type(), d)
+			}
+			ind.Set(val.Convert(ind.Type()))
+		}
+	}
+	return nil
+}
+
+// close rows.
+func (r *cachedRows) Close() error {
+	return nil
+}
+
+// get error of rows.
+func (r *cachedRows) Err() error {
+	return nil
+}
+
+// get cache option of query set with the tables of query, nil when query set is not cached.
+// query in transaction is not cached.
+func (o *querySet) queryCache(t *dbTables) *queryCache {
+	if o == nil || o.cache == nil || o.orm.isTx {
+		return nil
+	}
+	c := *o.cache
+	c.tables = []string{o.mi.table}
+	for _, tbl := range t.tables {
+		c.tables = append(c.tables, tbl.mi.table)
+	}
+	return &c
+}
+
+// get context of query set, nil when query is not for context.
+func (o *querySet) queryContext() context.Context {
+	if o != nil && o.forContext {
+		return o.ctx
+	}
+	return nil
+}
+
+// get cache option of raw set, nil when raw set is not cached.
+// query in transaction is not cached.
+func (o *rawSet) queryCache() *queryCache {
+	if o.cache == nil || o.orm.isTx {
+		return nil
+	}
+	return o.cache
+}
+
+// invalidate cached results of tables written by orm,
+// tables written in transaction are invalidated again when committed.
+func (o *orm) invalidate(tables ...string) {
+	if QueryCache == nil {
+		return
+	}
+	invalidateTables(o.alias.Name, tables...)
+	if o.isTx {
+		if o.txTables == nil {
+			o.txTables = make(map[string]bool)
+		}
+		for _, table := range tables {
+			o.txTables[table] = true
+		}
+	}
+}
+
+// get tables changed by delete of model, include the related tables deleted or updated by on_delete.
+func deleteTables(mi *modelInfo) []string {
+	tables := []string{mi.table}
+	for _, fi := range mi.fields.fieldsReverse {
+		tables = append(tables, fi.relModelInfo.table)
+		if fi.relThroughModelInfo != nil {
+			tables = append(tables, fi.relThroughModelInfo.table)
+		}
+	}
+	for _, fi := range mi.fields.fieldsRel {
+		if fi.fieldType == RelManyToMany {
+			tables = append(tables, fi.relThroughModelInfo.table)
+		}
+	}
+	return tables
+}
+
+// invalidate tables written in committed transaction.
+func (o *orm) invalidateTx() {
+	tables := make([]string, 0, len(o.txTables))
+	for table := range o.txTables {
+		tables = append(tables, table)
+	}
+	o.txTables = nil
+	invalidateTables(o.alias.Name, tables...)
+}
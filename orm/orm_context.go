@@ -0,0 +1,132 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// dbSetter is implemented by dbQuerier wrappers,
+// the wrapped querier is replaced when a transaction begins.
+type dbSetter interface {
+	SetDB(dbQuerier)
+}
+
+// dbCloner is implemented by dbQuerier wrappers,
+// SetDB of the copy does not change the wrapped querier of origin.
+type dbCloner interface {
+	cloneDB() dbQuerier
+}
+
+// copy the chain of wrappers of db, db which is not a wrapper is shared.
+func cloneDbQuerier(db dbQuerier) dbQuerier {
+	if c, ok := db.(dbCloner); ok {
+		return c.cloneDB()
+	}
+	return db
+}
+
+// database querier bound to a context.
+// every statement is executed with the context variants so
+// cancellation and deadline abort the running query.
+// queries are counted into QueryStats attached to the context.
+type dbQueryCtx struct {
+	ctx   context.Context
+	db    dbQuerier
+	stats *QueryStats
+}
+
+var _ dbQuerier = new(dbQueryCtx)
+var _ txer = new(dbQueryCtx)
+var _ txEnder = new(dbQueryCtx)
+
+func (d *dbQueryCtx) Prepare(query string) (*sql.Stmt, error) {
+	return d.db.PrepareContext(d.ctx, query)
+}
+
+func (d *dbQueryCtx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return d.db.PrepareContext(ctx, query)
+}
+
+func (d *dbQueryCtx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	defer d.observe(time.Now())
+	return d.db.ExecContext(d.ctx, query, args...)
+}
+
+func (d *dbQueryCtx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer d.observe(time.Now())
+	return d.db.ExecContext(ctx, query, args...)
+}
+
+func (d *dbQueryCtx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	defer d.observe(time.Now())
+	return d.db.QueryContext(d.ctx, query, args...)
+}
+
+func (d *dbQueryCtx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer d.observe(time.Now())
+	return d.db.QueryContext(ctx, query, args...)
+}
+
+func (d *dbQueryCtx) QueryRow(query string, args ...interface{}) *sql.Row {
+	defer d.observe(time.Now())
+	return d.db.QueryRowContext(d.ctx, query, args...)
+}
+
+func (d *dbQueryCtx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer d.observe(time.Now())
+	return d.db.QueryRowContext(ctx, query, args...)
+}
+
+func (d *dbQueryCtx) Begin() (*sql.Tx, error) {
+	return d.db.(txer).BeginTx(d.ctx, nil)
+}
+
+func (d *dbQueryCtx) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return d.db.(txer).BeginTx(ctx, opts)
+}
+
+func (d *dbQueryCtx) Commit() error {
+	return d.db.(txEnder).Commit()
+}
+
+func (d *dbQueryCtx) Rollback() error {
+	return d.db.(txEnder).Rollback()
+}
+
+func (d *dbQueryCtx) SetDB(db dbQuerier) {
+	if s, ok := d.db.(dbSetter); ok {
+		s.SetDB(db)
+	} else {
+		d.db = db
+	}
+}
+
+func (d *dbQueryCtx) cloneDB() dbQuerier {
+	n := *d
+	n.db = cloneDbQuerier(d.db)
+	return &n
+}
+
+// count query executed since t into stats of context.
+func (d *dbQueryCtx) observe(t time.Time) {
+	if d.stats != nil {
+		d.stats.observe(t)
+	}
+}
+
+// bind dbQuerier to context, a previous binding is replaced.
+func newDbQueryCtx(ctx context.Context, db dbQuerier) dbQuerier {
+	if d, ok := db.(*dbQueryCtx); ok {
+		db = d.db
+	}
+	d := new(dbQueryCtx)
+	d.ctx = ctx
+	d.db = db
+	d.stats = QueryStatsFromContext(ctx)
+	return d
+}
@@ -0,0 +1,238 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"bytes"
+	sqldriver "database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+// or does not match the ordering of query.
+var ErrInvalidCursor = errors.New("<QuerySeter> invalid cursor")
+
+// Cursor is the position of a row in ordered query, used for keyset pagination.
+// Values are the order column values of the row, Before mark the cursor
+// points to the previous page.
+type Cursor struct {
+	Values []interface{} `json:"v"`
+	Before bool          `json:"b,omitempty"`
+}
+
+// Encode return the opaque token of cursor.
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor decode the opaque cursor token.
+func DecodeCursor(token string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	c := new(Cursor)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(c); err != nil || len(c.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// get the order field used by keyset pagination, only not null model
+// column is supported, NULL values cannot be compared by `>` and `<`.
+func (o *querySet) cursorField(name string) (*fieldInfo, error) {
+	fi, ok := o.mi.fields.GetByAny(name)
+	if !ok || !fi.dbcol {
+		return nil, fmt.Errorf("<QuerySeter.Cursor> cannot use `%s` as cursor, only model column is supported", name)
+	}
+	if fi.null {
+		return nil, fmt.Errorf("<QuerySeter.Cursor> cannot use `%s` as cursor, column is nullable", name)
+	}
+	return fi, nil
+}
+
+// get the ordering used by keyset pagination,
+// pk is appended to make the ordering unique.
+func (o *querySet) cursorOrders() []string {
+	orders := make([]string, 0, len(o.orders)+1)
+	hasPk := false
+	for _, order := range o.orders {
+		name := strings.TrimPrefix(order, "-")
+		if fi, ok := o.mi.fields.GetByAny(name); ok && fi == o.mi.fields.pk {
+			hasPk = true
+		}
+		orders = append(orders, order)
+	}
+	if !hasPk {
+		orders = append(orders, o.mi.fields.pk.name)
+	}
+	return orders
+}
+
+// prepare query set and condition for keyset pagination.
+func (o *querySet) cursorQuery() (*querySet, *Condition, error) {
+	if o.cursor == nil {
+		return o, o.cond, nil
+	}
+
+	orders := o.cursorOrders()
+	if len(orders) != len(o.cursor.Values) {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(orders))
+	for i, order := range orders {
+		fi, err := o.cursorField(strings.TrimPrefix(order, "-"))
+		if err != nil {
+			return nil, nil, err
+		}
+		v, err := cursorValue(fi, o.cursor.Values[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		values[i] = v
+	}
+
+	// (a > ?) OR (a = ? AND b > ?) ...
+	cursor := NewCondition()
+	for i, order := range orders {
+		name := strings.TrimPrefix(order, "-")
+		desc := name != order
+
+		cond := NewCondition()
+		for j := 0; j < i; j++ {
+			cond = cond.And(strings.TrimPrefix(orders[j], "-"), values[j])
+		}
+		if desc != o.cursorBefore {
+			cond = cond.And(name+ExprSep+"lt", values[i])
+		} else {
+			cond = cond.And(name+ExprSep+"gt", values[i])
+		}
+		cursor = cursor.OrCond(cond)
+	}
+
+	cond := o.cond
+	if cond == nil {
+		cond = NewCondition()
+	}
+	cond = cond.AndCond(cursor)
+
+	qs := *o
+	qs.offset = 0
+	qs.orders = orders
+	if o.cursorBefore {
+		// read backward, the result is reversed afterwards
+		qs.orders = make([]string, len(orders))
+		for i, order := range orders {
+			if strings.HasPrefix(order, "-") {
+				qs.orders[i] = order[1:]
+			} else {
+				qs.orders[i] = "-" + order
+			}
+		}
+	}
+	return &qs, cond, nil
+}
+
+// convert decoded cursor value to the type of order field.
+func cursorValue(fi *fieldInfo, value interface{}) (interface{}, error) {
+	if fi.rel {
+		fi = fi.relModelInfo.fields.pk
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		switch {
+		case fi.fieldType&IsIntegerField > 0:
+			return v.Int64()
+		case fi.fieldType == TypeFloatField || fi.fieldType == TypeDecimalField:
+			return v.Float64()
+		}
+		return v.String(), nil
+	case string:
+		switch fi.fieldType {
+		case TypeTimeField, TypeDateField, TypeDateTimeField:
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			return t, nil
+		}
+		return v, nil
+	case bool:
+		return v, nil
+	}
+	return nil, ErrInvalidCursor
+}
+
+// generate cursor of model md for the ordering of query.
+func (o *querySet) cursorOf(md interface{}, before bool) (string, error) {
+	ind := reflect.Indirect(reflect.ValueOf(md))
+	if ind.Kind() != reflect.Struct || getFullName(ind.Type()) != o.mi.fullName {
+		return "", fmt.Errorf("<QuerySeter.Cursor> wrong object type `%T`, need *%s", md, o.mi.fullName)
+	}
+
+	orders := o.cursorOrders()
+	c := &Cursor{Values: make([]interface{}, 0, len(orders)), Before: before}
+	for _, order := range orders {
+		name := strings.TrimPrefix(order, "-")
+		fi, err := o.cursorField(name)
+		if err != nil {
+			return "", err
+		}
+
+		var value interface{}
+		if fi.pk {
+			value, _ = getPkValue(fi, ind)
+		} else {
+			field := ind.FieldByIndex(fi.fieldIndex)
+			switch {
+			case fi.rel:
+				if !field.IsNil() {
+					_, value, _ = getExistPk(fi.relModelInfo, reflect.Indirect(field))
+				}
+			case fi.isFielder:
+				value = field.Addr().Interface().(Fielder).RawValue()
+			case field.Kind() == reflect.Ptr:
+				if !field.IsNil() {
+					value = field.Elem().Interface()
+				}
+			default:
+				value = field.Interface()
+			}
+			// sql.Null* values are encoded by their driver value
+			if vu, ok := value.(sqldriver.Valuer); ok {
+				value, _ = vu.Value()
+			}
+		}
+		if value == nil {
+			return "", fmt.Errorf("<QuerySeter.Cursor> cannot use `%s` as cursor, value is NULL", name)
+		}
+		c.Values = append(c.Values, value)
+	}
+	return c.Encode(), nil
+}
+
+// reverse slice container read backward by Before cursor.
+func reverseContainer(container interface{}) {
+	ind := reflect.Indirect(reflect.ValueOf(container))
+	if ind.Kind() != reflect.Slice {
+		return
+	}
+	swap := reflect.Swapper(ind.Interface())
+	for i, j := 0, ind.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"reflect"
+	"time"
+)
+
+// Tracker records field values of model loaded by Read, One or All, or saved by Insert or Update.
+// embed it into model to update the changed fields only when Ormer.Update is called without cols.
+// for example:
+//	type User struct {
+//		orm.Tracker
+//		ID   int
+//		Name string
+//	}
+type Tracker struct {
+	snapshot map[string]interface{}
+}
+
+// get the tracker of model.
+func (t *Tracker) tracker() *Tracker {
+	return t
+}
+
+// model embed Tracker.
+type tracked interface {
+	tracker() *Tracker
+}
+
+// FieldChange is a field of tracked model changed since it is loaded or saved.
+type FieldChange struct {
+	Name   string
+	Column string
+	Old    interface{}
+	New    interface{}
+}
+
+// get the tracker of model, nil when model does not embed Tracker.
+func getTracker(ind reflect.Value) *Tracker {
+	if !ind.CanAddr() {
+		return nil
+	}
+	if t, ok := ind.Addr().Interface().(tracked); ok {
+		return t.tracker()
+	}
+	return nil
+}
+
+// check field is tracked, pk, version and auto_now fields are not tracked
+// because they are not written by changed value.
+func isTrackedField(mi *modelInfo, fi *fieldInfo) bool {
+	return !fi.pk && !fi.autoNow && fi != mi.fields.version
+}
+
+// record values of cols in model, empty cols record all fields.
+// cols are recorded only when model is tracked already.
+func snapshotModel(d dbBaser, mi *modelInfo, ind reflect.Value, tz *time.Location, cols []string) {
+	t := getTracker(ind)
+	if t == nil || t.snapshot == nil && len(cols) > 0 {
+		return
+	}
+
+	fis := mi.fields.fieldsDB
+	if len(cols) == 0 {
+		t.snapshot = make(map[string]interface{}, len(fis))
+	} else {
+		fis = make([]*fieldInfo, 0, len(cols))
+		for _, col := range cols {
+			if fi, ok := mi.fields.GetByAny(col); ok {
+				fis = append(fis, fi)
+			}
+		}
+	}
+
+	for _, fi := range fis {
+		if !isTrackedField(mi, fi) {
+			continue
+		}
+		if value, err := d.collectFieldValue(mi, fi, ind, false, tz); err == nil {
+			t.snapshot[fi.name] = value
+		}
+	}
+}
+
+// get changed fields of tracked model, ok is false when model is not tracked or not loaded.
+func modelChanges(d dbBaser, mi *modelInfo, ind reflect.Value, tz *time.Location) (changes []FieldChange, ok bool) {
+	t := getTracker(ind)
+	if t == nil || t.snapshot == nil {
+		return nil, false
+	}
+
+	for _, fi := range mi.fields.fieldsDB {
+		if !isTrackedField(mi, fi) {
+			continue
+		}
+		value, err := d.collectFieldValue(mi, fi, ind, false, tz)
+		if err != nil {
+			// invalid value is written to report the error
+			changes = append(changes, FieldChange{Name: fi.name, Column: fi.column, Old: t.snapshot[fi.name]})
+			continue
+		}
+		if old, ok := t.snapshot[fi.name]; !ok || !reflect.DeepEqual(old, value) {
+			changes = append(changes, FieldChange{Name: fi.name, Column: fi.column, Old: old, New: value})
+		}
+	}
+	return changes, true
+}
+
+// get the cols written by Update of tracked model, the changed and auto_now fields.
+func changedCols(mi *modelInfo, changes []FieldChange) []string {
+	cols := make([]string, 0, len(changes)+1)
+	for _, c := range changes {
+		cols = append(cols, c.Column)
+	}
+	for _, fi := range mi.fields.fieldsDB {
+		if fi.autoNow {
+			cols = append(cols, fi.column)
+		}
+	}
+	return cols
+}
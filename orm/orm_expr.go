@@ -0,0 +1,109 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ColExpr is a reference to model column, used as condition value to compare two columns.
+// for example:
+//	qs.Filter("updated__gt", orm.Col("created"))
+type ColExpr struct {
+	expr string
+}
+
+// Col create column reference of field expression, related field is supported, e.g. Col("profile__age").
+func Col(expr string) *ColExpr {
+	return &ColExpr{expr: expr}
+}
+
+// SubQuery is a query set selecting one column, used as condition value.
+// it is created by QuerySeter.ValuesExpr.
+type SubQuery struct {
+	qs   *querySet
+	expr string
+}
+
+// operators can compare with column or sub query.
+var exprOperators = map[string]bool{
+	"exact": true,
+	"eq":    true,
+	"ne":    true,
+	"gt":    true,
+	"gte":   true,
+	"lt":    true,
+	"lte":   true,
+}
+
+// generate sql of sub query, the `?` marks are kept for outer query.
+func (s *SubQuery) getSQL(d dbBaser, tz *time.Location) (string, []interface{}) {
+	qs := s.qs
+	mi := qs.mi
+	Q := d.TableQuote()
+
+	tables := newDbTables(mi, d)
+	tables.trashed = qs.trashed
+
+	index, _, fi, suc := tables.parseExprs(mi, strings.Split(s.expr, ExprSep))
+	if !suc {
+		panic(fmt.Errorf("unknown field/column name `%s`", s.expr))
+	}
+
+	where, args := tables.getCondSQL(qs.cond, false, tz)
+	groupBy := tables.getGroupSQL(qs.groups)
+	orderBy := tables.getOrderSQL(qs.orders)
+	limit := ""
+	if qs.limit != 0 || qs.offset != 0 {
+		limit = tables.getLimitSQL(mi, qs.offset, qs.limit)
+	}
+	join := tables.getJoinSQL()
+
+	sqlSelect := "SELECT"
+	if qs.distinct {
+		sqlSelect += " DISTINCT"
+	}
+	query := fmt.Sprintf("%s %s.%s%s%s FROM %s%s%s T0 %s%s%s%s%s", sqlSelect, index, Q, fi.column, Q,
+		Q, mi.table, Q, join, where, groupBy, orderBy, limit)
+	return strings.TrimSpace(query), args
+}
+
+// generate operator sql of column reference or sub query value,
+// ok is false when value is neither of them.
+func (t *dbTables) getExprOperatorSQL(operator string, args []interface{}, tz *time.Location) (sql string, params []interface{}, ok bool) {
+	if len(args) != 1 {
+		return
+	}
+
+	switch v := args[0].(type) {
+	case *ColExpr:
+		if !exprOperators[operator] {
+			panic(fmt.Errorf("operator `%s` cannot compare with column `%s`", operator, v.expr))
+		}
+		index, _, fi, suc := t.parseExprs(t.mi, strings.Split(v.expr, ExprSep))
+		if !suc {
+			panic(fmt.Errorf("unknown field/column name `%s`", v.expr))
+		}
+		Q := t.base.TableQuote()
+		col := fmt.Sprintf("%s.%s%s%s", index, Q, fi.column, Q)
+		sql = strings.Replace(t.base.OperatorSQL(operator), "?", col, 1)
+		return sql, nil, true
+
+	case *SubQuery:
+		sub, subArgs := v.getSQL(t.base, tz)
+		switch {
+		case operator == "in":
+			sql = fmt.Sprintf("IN (%s)", sub)
+		case exprOperators[operator]:
+			sql = strings.Replace(t.base.OperatorSQL(operator), "?", "("+sub+")", 1)
+		default:
+			panic(fmt.Errorf("operator `%s` cannot compare with sub query", operator))
+		}
+		return sql, subArgs, true
+	}
+	return
+}
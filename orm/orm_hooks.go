@@ -0,0 +1,101 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import "reflect"
+
+// BeforeInserter is called before the model is inserted,
+// an error aborts the insert.
+type BeforeInserter interface {
+	BeforeInsert(o Ormer) error
+}
+
+// AfterInserter is called after the model is inserted and pk is set.
+type AfterInserter interface {
+	AfterInsert(o Ormer) error
+}
+
+// BeforeUpdater is called before the model is updated,
+// an error aborts the update.
+type BeforeUpdater interface {
+	BeforeUpdate(o Ormer) error
+}
+
+// AfterUpdater is called after the model is updated.
+type AfterUpdater interface {
+	AfterUpdate(o Ormer) error
+}
+
+// BeforeDeleter is called before the model is deleted,
+// an error aborts the delete.
+type BeforeDeleter interface {
+	BeforeDelete(o Ormer) error
+}
+
+// AfterDeleter is called after the model is deleted.
+type AfterDeleter interface {
+	AfterDelete(o Ormer) error
+}
+
+// AfterReader is called after the model is read from database.
+type AfterReader interface {
+	AfterRead(o Ormer) error
+}
+
+// hook names.
+const (
+	hookBeforeInsert = iota
+	hookAfterInsert
+	hookBeforeUpdate
+	hookAfterUpdate
+	hookBeforeDelete
+	hookAfterDelete
+	hookAfterRead
+)
+
+// call the hook implemented by model md.
+// the ormer is handed to hook so queries made by hook
+// run in the same transaction when one is active.
+func callHook(o Ormer, md interface{}, hook int) error {
+	switch hook {
+	case hookBeforeInsert:
+		if h, ok := md.(BeforeInserter); ok {
+			return h.BeforeInsert(o)
+		}
+	case hookAfterInsert:
+		if h, ok := md.(AfterInserter); ok {
+			return h.AfterInsert(o)
+		}
+	case hookBeforeUpdate:
+		if h, ok := md.(BeforeUpdater); ok {
+			return h.BeforeUpdate(o)
+		}
+	case hookAfterUpdate:
+		if h, ok := md.(AfterUpdater); ok {
+			return h.AfterUpdate(o)
+		}
+	case hookBeforeDelete:
+		if h, ok := md.(BeforeDeleter); ok {
+			return h.BeforeDelete(o)
+		}
+	case hookAfterDelete:
+		if h, ok := md.(AfterDeleter); ok {
+			return h.AfterDelete(o)
+		}
+	case hookAfterRead:
+		if h, ok := md.(AfterReader); ok {
+			return h.AfterRead(o)
+		}
+	}
+	return nil
+}
+
+// get the model pointer used to call hooks.
+func hookModel(ind reflect.Value) interface{} {
+	if ind.CanAddr() {
+		return ind.Addr().Interface()
+	}
+	return ind.Interface()
+}
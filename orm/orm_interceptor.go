@@ -0,0 +1,347 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueryInfo is the statement executed through an alias, passed to interceptors.
+// interceptors can rewrite Query and Args before calling next.
+type QueryInfo struct {
+	Alias     string
+	Operation string // first keyword of query, such as SELECT, INSERT or COMMIT
+	Table     string // main table of query, empty when it cannot be found
+	Query     string
+	Args      []interface{}
+	// query is executed by prepared statement, rewritten Query is ignored.
+	Stmt bool
+	// time taken by the database, set when next returns, zero when query is rejected by later interceptor.
+	Duration time.Duration
+}
+
+// QueryInvoker executes the statement, or calls the next interceptor of chain.
+type QueryInvoker func(ctx context.Context, q *QueryInfo) error
+
+// QueryInterceptor is called around every statement executed through an alias.
+// it observes the statement, rewrites it before calling next,
+// or rejects it by returning an error without calling next.
+// example:
+//	orm.AddQueryInterceptor("default", func(ctx context.Context, q *orm.QueryInfo, next orm.QueryInvoker) error {
+//		if q.Table == "order" && q.Operation != "INSERT" && !strings.Contains(q.Query, "tenant_id") {
+//			return errors.New("query of order must be scoped by tenant")
+//		}
+//		return next(ctx, q)
+//	})
+type QueryInterceptor func(ctx context.Context, q *QueryInfo, next QueryInvoker) error
+
+// guard interceptors of aliases.
+var interceptorsMux sync.RWMutex
+
+// AddQueryInterceptor add interceptors to the chain of alias, they are called in order of adding.
+// queries of alias routed to its replicas are intercepted too.
+// interceptors are applied to the Ormer created after they are added.
+func AddQueryInterceptor(aliasName string, interceptors ...QueryInterceptor) error {
+	al, ok := dataBaseCache.get(aliasName)
+	if !ok {
+		return fmt.Errorf("DataBase alias name `%s` not registered", aliasName)
+	}
+	interceptorsMux.Lock()
+	defer interceptorsMux.Unlock()
+	// copy on append, chains of created Ormer are not changed
+	n := len(al.interceptors)
+	al.interceptors = append(al.interceptors[:n:n], interceptors...)
+	return nil
+}
+
+// ResetQueryInterceptors remove all interceptors of alias.
+func ResetQueryInterceptors(aliasName string) error {
+	al, ok := dataBaseCache.get(aliasName)
+	if !ok {
+		return fmt.Errorf("DataBase alias name `%s` not registered", aliasName)
+	}
+	interceptorsMux.Lock()
+	defer interceptorsMux.Unlock()
+	al.interceptors = nil
+	return nil
+}
+
+// get current chain of interceptors of alias.
+func (al *alias) getInterceptors() []QueryInterceptor {
+	interceptorsMux.RLock()
+	defer interceptorsMux.RUnlock()
+	return al.interceptors
+}
+
+// wrap querier of alias by logger and interceptors.
+func wrapDbQuerier(al *alias, interceptors []QueryInterceptor, db dbQuerier) dbQuerier {
+	if queryLogEnabled() {
+		db = newDbQueryLog(al, db)
+	}
+	if len(interceptors) > 0 {
+		db = newDbQueryIntercept(al, interceptors, db)
+	}
+	return db
+}
+
+// wrap prepared statement of alias by logger and interceptors.
+func wrapStmtQuerier(al *alias, interceptors []QueryInterceptor, stmt stmtQuerier, query string) stmtQuerier {
+	if queryLogEnabled() {
+		stmt = newStmtQueryLog(al, stmt, query)
+	}
+	if len(interceptors) > 0 {
+		stmt = newStmtQueryIntercept(al, interceptors, stmt, query)
+	}
+	return stmt
+}
+
+// call the chain of interceptors, invoke executes the statement at the end of chain.
+// the chain must call invoke or return an error.
+func interceptQuery(ctx context.Context, interceptors []QueryInterceptor, q *QueryInfo, invoke QueryInvoker) error {
+	q.Operation, q.Table = parseQuery(q.Query)
+
+	invoked := false
+	next := func(ctx context.Context, q *QueryInfo) error {
+		invoked = true
+		t := time.Now()
+		err := invoke(ctx, q)
+		q.Duration = time.Since(t)
+		return err
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, n := interceptors[i], next
+		next = func(ctx context.Context, q *QueryInfo) error {
+			return interceptor(ctx, q, n)
+		}
+	}
+	err := next(ctx, q)
+	if err == nil && !invoked {
+		err = fmt.Errorf("<QueryInterceptor> query `%s` is not executed", q.Query)
+	}
+	return err
+}
+
+// database always failed to connect, used to create sql.Row of rejected query.
+type rejectedDB struct {
+	err error
+}
+
+func (d rejectedDB) Open(name string) (sqldriver.Conn, error) {
+	return nil, d.err
+}
+
+func (d rejectedDB) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	return nil, d.err
+}
+
+func (d rejectedDB) Driver() sqldriver.Driver {
+	return d
+}
+
+// get sql.Row which Scan returns err.
+func rejectedRow(err error) *sql.Row {
+	db := sql.OpenDB(rejectedDB{err})
+	defer db.Close()
+	return db.QueryRow("")
+}
+
+// database querier intercepted by chain of alias.
+type dbQueryIntercept struct {
+	alias        *alias
+	interceptors []QueryInterceptor
+	db           dbQuerier
+}
+
+var _ dbQuerier = new(dbQueryIntercept)
+var _ txer = new(dbQueryIntercept)
+var _ txEnder = new(dbQueryIntercept)
+
+func (d *dbQueryIntercept) intercept(ctx context.Context, query string, args []interface{}, invoke QueryInvoker) error {
+	q := &QueryInfo{Alias: d.alias.Name, Query: query, Args: args}
+	return interceptQuery(ctx, d.interceptors, q, invoke)
+}
+
+func (d *dbQueryIntercept) Prepare(query string) (*sql.Stmt, error) {
+	return d.PrepareContext(context.Background(), query)
+}
+
+func (d *dbQueryIntercept) PrepareContext(ctx context.Context, query string) (stmt *sql.Stmt, err error) {
+	err = d.intercept(ctx, query, nil, func(ctx context.Context, q *QueryInfo) (err error) {
+		stmt, err = d.db.PrepareContext(ctx, q.Query)
+		return
+	})
+	if err != nil && stmt != nil {
+		stmt.Close()
+		return nil, err
+	}
+	return
+}
+
+func (d *dbQueryIntercept) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.ExecContext(context.Background(), query, args...)
+}
+
+func (d *dbQueryIntercept) ExecContext(ctx context.Context, query string, args ...interface{}) (res sql.Result, err error) {
+	err = d.intercept(ctx, query, args, func(ctx context.Context, q *QueryInfo) (err error) {
+		res, err = d.db.ExecContext(ctx, q.Query, q.Args...)
+		return
+	})
+	return
+}
+
+func (d *dbQueryIntercept) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.QueryContext(context.Background(), query, args...)
+}
+
+func (d *dbQueryIntercept) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	err = d.intercept(ctx, query, args, func(ctx context.Context, q *QueryInfo) (err error) {
+		rows, err = d.db.QueryContext(ctx, q.Query, q.Args...)
+		return
+	})
+	if err != nil && rows != nil {
+		rows.Close()
+		return nil, err
+	}
+	return
+}
+
+func (d *dbQueryIntercept) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.QueryRowContext(context.Background(), query, args...)
+}
+
+func (d *dbQueryIntercept) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	err := d.intercept(ctx, query, args, func(ctx context.Context, q *QueryInfo) error {
+		row = d.db.QueryRowContext(ctx, q.Query, q.Args...)
+		return nil
+	})
+	if err != nil {
+		if row != nil {
+			// release connection of row
+			row.Scan()
+		}
+		return rejectedRow(err)
+	}
+	return row
+}
+
+func (d *dbQueryIntercept) Begin() (*sql.Tx, error) {
+	return d.BeginTx(context.Background(), nil)
+}
+
+func (d *dbQueryIntercept) BeginTx(ctx context.Context, opts *sql.TxOptions) (tx *sql.Tx, err error) {
+	err = d.intercept(ctx, "START TRANSACTION", nil, func(ctx context.Context, q *QueryInfo) (err error) {
+		tx, err = d.db.(txer).BeginTx(ctx, opts)
+		return
+	})
+	if err != nil && tx != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return
+}
+
+func (d *dbQueryIntercept) Commit() error {
+	return d.intercept(context.Background(), "COMMIT", nil, func(ctx context.Context, q *QueryInfo) error {
+		return d.db.(txEnder).Commit()
+	})
+}
+
+func (d *dbQueryIntercept) Rollback() error {
+	return d.intercept(context.Background(), "ROLLBACK", nil, func(ctx context.Context, q *QueryInfo) error {
+		return d.db.(txEnder).Rollback()
+	})
+}
+
+func (d *dbQueryIntercept) SetDB(db dbQuerier) {
+	if s, ok := d.db.(dbSetter); ok {
+		s.SetDB(db)
+	} else {
+		d.db = db
+	}
+}
+
+func (d *dbQueryIntercept) cloneDB() dbQuerier {
+	n := *d
+	n.db = cloneDbQuerier(d.db)
+	return &n
+}
+
+func newDbQueryIntercept(alias *alias, interceptors []QueryInterceptor, db dbQuerier) dbQuerier {
+	d := new(dbQueryIntercept)
+	d.alias = alias
+	d.interceptors = interceptors
+	d.db = db
+	return d
+}
+
+// prepared statement intercepted by chain of alias.
+type stmtQueryIntercept struct {
+	alias        *alias
+	interceptors []QueryInterceptor
+	query        string
+	stmt         stmtQuerier
+}
+
+var _ stmtQuerier = new(stmtQueryIntercept)
+
+func (d *stmtQueryIntercept) intercept(args []interface{}, invoke QueryInvoker) error {
+	q := &QueryInfo{Alias: d.alias.Name, Query: d.query, Args: args, Stmt: true}
+	return interceptQuery(context.Background(), d.interceptors, q, invoke)
+}
+
+func (d *stmtQueryIntercept) Close() error {
+	return d.stmt.Close()
+}
+
+func (d *stmtQueryIntercept) Exec(args ...interface{}) (res sql.Result, err error) {
+	err = d.intercept(args, func(ctx context.Context, q *QueryInfo) (err error) {
+		res, err = d.stmt.Exec(q.Args...)
+		return
+	})
+	return
+}
+
+func (d *stmtQueryIntercept) Query(args ...interface{}) (rows *sql.Rows, err error) {
+	err = d.intercept(args, func(ctx context.Context, q *QueryInfo) (err error) {
+		rows, err = d.stmt.Query(q.Args...)
+		return
+	})
+	if err != nil && rows != nil {
+		rows.Close()
+		return nil, err
+	}
+	return
+}
+
+func (d *stmtQueryIntercept) QueryRow(args ...interface{}) *sql.Row {
+	var row *sql.Row
+	err := d.intercept(args, func(ctx context.Context, q *QueryInfo) error {
+		row = d.stmt.QueryRow(q.Args...)
+		return nil
+	})
+	if err != nil {
+		if row != nil {
+			// release connection of row
+			row.Scan()
+		}
+		return rejectedRow(err)
+	}
+	return row
+}
+
+func newStmtQueryIntercept(alias *alias, interceptors []QueryInterceptor, stmt stmtQuerier, query string) stmtQuerier {
+	d := new(stmtQueryIntercept)
+	d.alias = alias
+	d.interceptors = interceptors
+	d.stmt = stmt
+	d.query = query
+	return d
+}
@@ -19,6 +19,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"path"
 	"runtime"
 	"strings"
 	"time"
@@ -34,22 +35,37 @@ func NewLog() *zap.Logger {
 	return d
 }
 
-func ormCaller(skipFrames int) string {
-	// We need the frame at index skipFrames+2, since we never want runtime.Callers and getFrame
-	targetFrameIndex := skipFrames + 2
-
-	// Set size to targetFrameIndex+2 to ensure we have room for one more caller than we need
-	programCounters := make([]uintptr, targetFrameIndex+2)
-	n := runtime.Callers(0, programCounters)
+// get the caller of orm, it is the first frame out of the files of orm package,
+// interceptors called by orm inside the query are skipped.
+func ormCaller() string {
+	programCounters := make([]uintptr, 64)
+	// skip runtime.Callers and ormCaller, the first frame is in orm package
+	n := runtime.Callers(2, programCounters)
+
+	var stack []runtime.Frame
+	frames := runtime.CallersFrames(programCounters[:n])
+	for more := n > 0; more; {
+		var frameCandidate runtime.Frame
+		frameCandidate, more = frames.Next()
+		stack = append(stack, frameCandidate)
+	}
 
 	frame := runtime.Frame{Function: "unknown"}
-	if n > 0 {
-		frames := runtime.CallersFrames(programCounters[:n])
-		for more, frameIndex := true, 0; more && frameIndex <= targetFrameIndex; frameIndex++ {
-			var frameCandidate runtime.Frame
-			frameCandidate, more = frames.Next()
-			if frameIndex == targetFrameIndex {
-				frame = frameCandidate
+	if len(stack) > 0 {
+		dir := path.Dir(stack[0].File)
+		inOrm := func(f runtime.Frame) bool {
+			return path.Dir(f.File) == dir && !strings.HasSuffix(f.File, "_test.go")
+		}
+		start := 0
+		for i, f := range stack {
+			if inOrm(f) && strings.HasSuffix(f.Function, ".interceptQuery") {
+				start = i
+			}
+		}
+		for _, f := range stack[start:] {
+			if !inOrm(f) {
+				frame = f
+				break
 			}
 		}
 	}
@@ -68,8 +84,20 @@ func ormCaller(skipFrames int) string {
 	return fmt.Sprintf("%s:%d", fn[idx+1:], frame.Line)
 }
 
-func logQuery(query string, t time.Time, err error, args ...interface{}) {
-	sub := time.Now().Sub(t) / 1e5
+// log query for debug or when it is slow, and observe it by metrics.
+func logQuery(alias *alias, query string, t time.Time, err error, args ...interface{}) {
+	elapsed := time.Since(t)
+	if Metrics != nil {
+		operation, table := parseQuery(query)
+		Metrics.ObserveQuery(alias.Name, operation, table, elapsed, err)
+	}
+
+	slow := SlowQueryThreshold > 0 && elapsed >= SlowQueryThreshold
+	if !Debug && !slow {
+		return
+	}
+
+	sub := elapsed / 1e5
 	elsp := float64(int(sub)) / 10.0
 
 	query = strings.Replace(query, "`", "", -1)
@@ -77,18 +105,20 @@ func logQuery(query string, t time.Time, err error, args ...interface{}) {
 	var fields = []zap.Field{
 		zap.String("query", query),
 		zap.String("latecy", fmt.Sprintf("%1.1fms", elsp)),
-		zap.String("caller", ormCaller(4)),
+		zap.String("caller", ormCaller()),
 	}
 
-	if err == nil {
-		DebugLog.Debug(fmt.Sprintf("ORM/%s", "OK"), fields...)
-	} else {
+	if err != nil && Debug {
 		DebugLog.Error(fmt.Sprintf("ORM/%s", "FAIL"), fields...)
+	} else if slow {
+		DebugLog.Warn(fmt.Sprintf("ORM/%s", "SLOW"), fields...)
+	} else {
+		DebugLog.Debug(fmt.Sprintf("ORM/%s", "OK"), fields...)
 	}
 }
 
 // statement query logger struct.
-// if dev mode, use stmtQueryLog, or use stmtQuerier.
+// if dev mode, slow query log or metrics is enabled, use stmtQueryLog, or use stmtQuerier.
 type stmtQueryLog struct {
 	alias *alias
 	query string
@@ -100,28 +130,28 @@ var _ stmtQuerier = new(stmtQueryLog)
 func (d *stmtQueryLog) Close() error {
 	a := time.Now()
 	err := d.stmt.Close()
-	logQuery(d.query, a, err)
+	logQuery(d.alias, d.query, a, err)
 	return err
 }
 
 func (d *stmtQueryLog) Exec(args ...interface{}) (sql.Result, error) {
 	a := time.Now()
 	res, err := d.stmt.Exec(args...)
-	logQuery(d.query, a, err, args...)
+	logQuery(d.alias, d.query, a, err, args...)
 	return res, err
 }
 
 func (d *stmtQueryLog) Query(args ...interface{}) (*sql.Rows, error) {
 	a := time.Now()
 	res, err := d.stmt.Query(args...)
-	logQuery(d.query, a, err, args...)
+	logQuery(d.alias, d.query, a, err, args...)
 	return res, err
 }
 
 func (d *stmtQueryLog) QueryRow(args ...interface{}) *sql.Row {
 	a := time.Now()
 	res := d.stmt.QueryRow(args...)
-	logQuery(d.query, a, nil, args...)
+	logQuery(d.alias, d.query, a, nil, args...)
 	return res
 }
 
@@ -134,7 +164,7 @@ func newStmtQueryLog(alias *alias, stmt stmtQuerier, query string) stmtQuerier {
 }
 
 // database query logger struct.
-// if dev mode, use dbQueryLog, or use dbQuerier.
+// if dev mode, slow query log or metrics is enabled, use dbQueryLog, or use dbQuerier.
 type dbQueryLog struct {
 	alias *alias
 	db    dbQuerier
@@ -149,84 +179,84 @@ var _ txEnder = new(dbQueryLog)
 func (d *dbQueryLog) Prepare(query string) (*sql.Stmt, error) {
 	a := time.Now()
 	stmt, err := d.db.Prepare(query)
-	logQuery(query, a, err)
+	logQuery(d.alias, query, a, err)
 	return stmt, err
 }
 
 func (d *dbQueryLog) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
 	a := time.Now()
 	stmt, err := d.db.PrepareContext(ctx, query)
-	logQuery(query, a, err)
+	logQuery(d.alias, query, a, err)
 	return stmt, err
 }
 
 func (d *dbQueryLog) Exec(query string, args ...interface{}) (sql.Result, error) {
 	a := time.Now()
 	res, err := d.db.Exec(query, args...)
-	logQuery(query, a, err, args...)
+	logQuery(d.alias, query, a, err, args...)
 	return res, err
 }
 
 func (d *dbQueryLog) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	a := time.Now()
 	res, err := d.db.ExecContext(ctx, query, args...)
-	logQuery(query, a, err, args...)
+	logQuery(d.alias, query, a, err, args...)
 	return res, err
 }
 
 func (d *dbQueryLog) Query(query string, args ...interface{}) (*sql.Rows, error) {
 	a := time.Now()
 	res, err := d.db.Query(query, args...)
-	logQuery(query, a, err, args...)
+	logQuery(d.alias, query, a, err, args...)
 	return res, err
 }
 
 func (d *dbQueryLog) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	a := time.Now()
 	res, err := d.db.QueryContext(ctx, query, args...)
-	logQuery(query, a, err, args...)
+	logQuery(d.alias, query, a, err, args...)
 	return res, err
 }
 
 func (d *dbQueryLog) QueryRow(query string, args ...interface{}) *sql.Row {
 	a := time.Now()
 	res := d.db.QueryRow(query, args...)
-	logQuery(query, a, nil, args...)
+	logQuery(d.alias, query, a, nil, args...)
 	return res
 }
 
 func (d *dbQueryLog) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
 	a := time.Now()
 	res := d.db.QueryRowContext(ctx, query, args...)
-	logQuery(query, a, nil, args...)
+	logQuery(d.alias, query, a, nil, args...)
 	return res
 }
 
 func (d *dbQueryLog) Begin() (*sql.Tx, error) {
 	a := time.Now()
 	tx, err := d.db.(txer).Begin()
-	logQuery("START TRANSACTION", a, err)
+	logQuery(d.alias, "START TRANSACTION", a, err)
 	return tx, err
 }
 
 func (d *dbQueryLog) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
 	a := time.Now()
 	tx, err := d.db.(txer).BeginTx(ctx, opts)
-	logQuery("START TRANSACTION", a, err)
+	logQuery(d.alias, "START TRANSACTION", a, err)
 	return tx, err
 }
 
 func (d *dbQueryLog) Commit() error {
 	a := time.Now()
 	err := d.db.(txEnder).Commit()
-	logQuery("COMMIT", a, err)
+	logQuery(d.alias, "COMMIT", a, err)
 	return err
 }
 
 func (d *dbQueryLog) Rollback() error {
 	a := time.Now()
 	err := d.db.(txEnder).Rollback()
-	logQuery("ROLLBACK", a, err)
+	logQuery(d.alias, "ROLLBACK", a, err)
 	return err
 }
 
@@ -234,6 +264,12 @@ func (d *dbQueryLog) SetDB(db dbQuerier) {
 	d.db = db
 }
 
+func (d *dbQueryLog) cloneDB() dbQuerier {
+	n := *d
+	n.db = cloneDbQuerier(d.db)
+	return &n
+}
+
 func newDbQueryLog(alias *alias, db dbQuerier) dbQuerier {
 	d := new(dbQueryLog)
 	d.alias = alias
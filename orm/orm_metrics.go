@@ -0,0 +1,91 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Define vars of query observability
+var (
+	// queries take longer than SlowQueryThreshold are logged as warning with their caller, 0 disable it.
+	SlowQueryThreshold time.Duration
+	// Metrics observe every query when it is set.
+	Metrics QueryMetrics
+)
+
+// QueryMetrics receives every query executed by orm, set it to orm.Metrics
+// to export counters and latency histograms to a monitoring system.
+// operation is the first keyword of query, such as SELECT, INSERT, UPDATE, DELETE or COMMIT,
+// table is empty when it cannot be found in query.
+type QueryMetrics interface {
+	ObserveQuery(alias, operation, table string, elapsed time.Duration, err error)
+}
+
+// QueryStats counts queries and their time of a context, such as an http request.
+// it is safe for concurrent use.
+type QueryStats struct {
+	count    int64
+	duration int64
+}
+
+// Count get the number of queries.
+func (s *QueryStats) Count() int64 {
+	return atomic.LoadInt64(&s.count)
+}
+
+// Duration get the total time of queries.
+func (s *QueryStats) Duration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.duration))
+}
+
+// add a query executed since t.
+func (s *QueryStats) observe(t time.Time) {
+	atomic.AddInt64(&s.count, 1)
+	atomic.AddInt64(&s.duration, int64(time.Since(t)))
+}
+
+type queryStatsKey struct{}
+
+// WithQueryStats attach new QueryStats to ctx, queries of Ormer.WithContext(ctx) are counted into it.
+// example:
+//	ctx, stats := orm.WithQueryStats(r.Context())
+//	o.WithContext(ctx).Read(&user)
+//	log.Printf("%d queries, %s", stats.Count(), stats.Duration())
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	s := new(QueryStats)
+	return context.WithValue(ctx, queryStatsKey{}, s), s
+}
+
+// QueryStatsFromContext get QueryStats attached to ctx, nil when ctx has no stats.
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	s, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return s
+}
+
+// check queries need to be wrapped by logger for debug, slow query or metrics.
+func queryLogEnabled() bool {
+	return Debug || SlowQueryThreshold > 0 || Metrics != nil
+}
+
+var queryTablePattern = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE)\\s+[`\"]?(\\w+)")
+
+// get operation and the main table of query.
+func parseQuery(query string) (operation, table string) {
+	query = strings.TrimSpace(query)
+	if i := strings.IndexAny(query, " \t\n"); i > 0 {
+		operation = strings.ToUpper(query[:i])
+	} else {
+		operation = strings.ToUpper(query)
+	}
+	if m := queryTablePattern.FindStringSubmatch(query); m != nil {
+		table = m[1]
+	}
+	return
+}
@@ -48,6 +48,7 @@ func (o *insertSet) Insert(md interface{}) (int64, error) {
 	if err != nil {
 		return id, err
 	}
+	o.orm.invalidate(o.mi.table)
 	if id > 0 {
 		if o.mi.fields.pk.auto {
 			if o.mi.fields.pk.fieldType&IsPositiveIntegerField > 0 {
@@ -78,10 +79,6 @@ func newInsertSet(orm *orm, mi *modelInfo) (Inserter, error) {
 	if err != nil {
 		return nil, err
 	}
-	if Debug {
-		bi.stmt = newStmtQueryLog(orm.alias, st, query)
-	} else {
-		bi.stmt = st
-	}
+	bi.stmt = wrapStmtQuerier(orm.alias, orm.interceptors, st, query)
 	return bi, nil
 }
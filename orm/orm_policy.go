@@ -0,0 +1,193 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/raryanda/go/utility"
+	"github.com/raryanda/go/validation"
+)
+
+// RequestPolicy is the whitelist of request query parameters of an endpoint.
+// parameters not allowed are skipped by RequestQuery.Apply and reported by RequestQuery.Validate.
+// example:
+//	policy := &orm.RequestPolicy{
+//		Filters:  map[string][]string{"user_name": {"exact", "icontains"}, "profile__age": nil},
+//		OrderBy:  []string{"id", "user_name"},
+//		Embeds:   []string{"profile"},
+//		MaxLimit: 100,
+//	}
+type RequestPolicy struct {
+	// allowed filter fields and their lookup operators, such as "icontains", "in", "null".
+	// empty operators allow all operators of the field.
+	Filters map[string][]string
+	// allowed orderby fields, both ascending and descending.
+	OrderBy []string
+	// allowed embeds.
+	Embeds []string
+	// maximum limit of rows, 0 means unlimited.
+	MaxLimit int
+}
+
+// lookup operators accepted in request conditions besides the orm operators.
+var requestOperators = map[string]bool{
+	"null":    true,
+	"notnull": true,
+}
+
+// split condition field expression into field and lookup operator.
+func splitLookup(expr string) (field string, operator string) {
+	exprs := strings.Split(expr, ExprSep)
+	num := len(exprs) - 1
+	if num > 0 && (operators[exprs[num]] || requestOperators[exprs[num]]) {
+		return strings.Join(exprs[:num], ExprSep), exprs[num]
+	}
+	return expr, "exact"
+}
+
+// check filter of field expression is allowed, nil policy allow all.
+func (p *RequestPolicy) allowFilter(expr string) bool {
+	if p == nil {
+		return true
+	}
+	field, operator := splitLookup(expr)
+	ops, ok := p.Filters[field]
+	if !ok {
+		return false
+	}
+	return len(ops) == 0 || utility.Contains(ops, operator)
+}
+
+// check ordering by field is allowed, nil policy allow all.
+func (p *RequestPolicy) allowOrder(order string) bool {
+	return p == nil || utility.Contains(p.OrderBy, strings.TrimPrefix(order, "-"))
+}
+
+// check embed is allowed, nil policy allow all.
+func (p *RequestPolicy) allowEmbed(embed string) bool {
+	return p == nil || utility.Contains(p.Embeds, embed)
+}
+
+// get the limit in range of policy.
+func (p *RequestPolicy) limit(limit int) int {
+	if p == nil || p.MaxLimit <= 0 {
+		return limit
+	}
+	if limit <= 0 || limit > p.MaxLimit {
+		return p.MaxLimit
+	}
+	return limit
+}
+
+// Validate check request query against the policy, and fields against the model when it is given,
+// model is a model pointer or table name as Ormer.QueryTable.
+// return *validation.Response describing rejected parameters, nil when valid or no policy and model.
+// example:
+//	if err := rq.Validate(new(User)); err != nil {
+//		return c.Serve(err)
+//	}
+func (rq *RequestQuery) Validate(model ...interface{}) error {
+	p := rq.Policy
+	if p == nil && len(model) == 0 {
+		return nil
+	}
+
+	res := validation.NewResponse()
+	if len(model) > 0 {
+		mi, ok := getModelInfo(model[0])
+		if !ok {
+			return fmt.Errorf("<RequestQuery.Validate> model `%v` is not registered", model[0])
+		}
+		rq.validateFields(res, mi)
+	}
+	for _, q := range rq.Conditions {
+		for k := range q {
+			field, _ := conditionKey(k)
+			if !p.allowFilter(field) {
+				res.Failure(fmt.Sprintf("conditions.%s.allowed", field), "filter is not allowed")
+			}
+		}
+	}
+	for _, order := range rq.OrderBy {
+		if !p.allowOrder(order) {
+			res.Failure(fmt.Sprintf("orderby.%s.allowed", strings.TrimPrefix(order, "-")), "order is not allowed")
+		}
+	}
+	for _, embed := range rq.Embeds {
+		if !p.allowEmbed(embed) {
+			res.Failure(fmt.Sprintf("embeds.%s.allowed", embed), "embed is not allowed")
+		}
+	}
+	if p != nil && p.MaxLimit > 0 && (rq.Limit < 0 || rq.Limit > p.MaxLimit) {
+		res.Failure("limit.max", fmt.Sprintf("must be between 1 and %d", p.MaxLimit))
+	}
+
+	if res.Valid {
+		return nil
+	}
+	return res
+}
+
+// check sparse fields are columns of model or of its embedded relations.
+func (rq *RequestQuery) validateFields(res *validation.Response, mi *modelInfo) {
+	var embeds []string
+	for _, embed := range rq.GetJoin() {
+		if names, _, ok := getRelatedNames(mi, strings.Split(embed.(string), ExprSep)); ok {
+			embeds = append(embeds, names)
+		}
+	}
+
+	for _, field := range rq.Fields {
+		exs := strings.Split(field, ExprSep)
+		names, mmi, ok := getRelatedNames(mi, exs[:len(exs)-1])
+		if ok {
+			fi, found := mmi.fields.GetByAny(exs[len(exs)-1])
+			ok = found && fi.dbcol
+		}
+		if !ok {
+			res.Failure(fmt.Sprintf("fields.%s.exist", field), "field is not found")
+			continue
+		}
+		if names == "" {
+			continue
+		}
+
+		embedded := false
+		for _, embed := range embeds {
+			if embed == names || strings.HasPrefix(embed, names+ExprSep) {
+				embedded = true
+				break
+			}
+		}
+		if !embedded {
+			res.Failure(fmt.Sprintf("fields.%s.embeds", field), fmt.Sprintf("field need embeds of %s", strings.Join(exs[:len(exs)-1], ExprSep)))
+		}
+	}
+}
+
+// get names of forward relations from model by field names, and the model of the last relation.
+func getRelatedNames(mi *modelInfo, exs []string) (string, *modelInfo, bool) {
+	names := make([]string, 0, len(exs))
+	for _, ex := range exs {
+		fi, ok := mi.fields.GetByAny(ex)
+		if !ok || !fi.rel || fi.fieldType == RelManyToMany {
+			return "", nil, false
+		}
+		names = append(names, fi.name)
+		mi = fi.relModelInfo
+	}
+	return strings.Join(names, ExprSep), mi, true
+}
+
+// get model info of model pointer or table name.
+func getModelInfo(model interface{}) (*modelInfo, bool) {
+	if table, ok := model.(string); ok {
+		return modelCache.get(nameStrategyMap[defaultNameStrategy](table))
+	}
+	return modelCache.getByFullName(getFullName(indirectType(reflect.TypeOf(model))))
+}
@@ -0,0 +1,267 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Prefetch is a relation loaded by QuerySeter.Prefetch with its own condition and ordering.
+type Prefetch struct {
+	name   string
+	cond   *Condition
+	orders []string
+}
+
+// NewPrefetch create prefetch of relation name, nested relation is joined by "__", e.g. "Posts__Comments".
+// condition and ordering are applied to the last relation of name.
+func NewPrefetch(name string) *Prefetch {
+	return &Prefetch{name: name}
+}
+
+// SetCond set condition of the related models.
+func (p *Prefetch) SetCond(cond *Condition) *Prefetch {
+	p.cond = cond
+	return p
+}
+
+// OrderBy set ordering of the related models.
+func (p *Prefetch) OrderBy(exprs ...string) *Prefetch {
+	p.orders = exprs
+	return p
+}
+
+// load prefetched relations into models of container read by All or One.
+func (o *querySet) prefetchRelated(container interface{}) error {
+	ind := reflect.Indirect(reflect.ValueOf(container))
+
+	var models []reflect.Value
+	if ind.Kind() == reflect.Slice {
+		models = make([]reflect.Value, ind.Len())
+		for i := range models {
+			models[i] = reflect.Indirect(ind.Index(i))
+		}
+	} else {
+		models = []reflect.Value{ind}
+	}
+	return o.prefetchModels(o.mi, models, o.prefetch)
+}
+
+// load relations of models, nested relations are loaded into the related models.
+func (o *querySet) prefetchModels(mi *modelInfo, models []reflect.Value, prefetch []*Prefetch) error {
+	var names []string
+	nested := make(map[string][]*Prefetch)
+	opts := make(map[string]*Prefetch)
+	for _, p := range prefetch {
+		exs := strings.SplitN(p.name, ExprSep, 2)
+		if _, ok := nested[exs[0]]; !ok {
+			names = append(names, exs[0])
+			nested[exs[0]] = nil
+		}
+		if len(exs) == 2 {
+			nested[exs[0]] = append(nested[exs[0]], &Prefetch{name: exs[1], cond: p.cond, orders: p.orders})
+		} else {
+			opts[exs[0]] = p
+		}
+	}
+
+	for _, name := range names {
+		fi, ok := mi.fields.GetByAny(name)
+		if !ok || !fi.inModel || !fi.rel && !fi.reverse {
+			return fmt.Errorf("<QuerySeter.Prefetch> name `%s` for model `%s` is not an available rel/reverse field", name, mi.fullName)
+		}
+
+		related, err := o.prefetchField(mi, fi, models, opts[name])
+		if err != nil {
+			return err
+		}
+		if len(nested[name]) > 0 && len(related) > 0 {
+			if err := o.prefetchModels(fi.relModelInfo, related, nested[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// create query set of related model by options of prefetch.
+func (o *querySet) prefetchQs(mi *modelInfo, p *Prefetch) *querySet {
+	q := newQuerySet(o.orm, mi).(*querySet)
+	q.limit = -1
+	q.ctx = o.ctx
+	q.forContext = o.forContext
+	q.cond = NewCondition()
+	if p != nil {
+		if p.cond != nil {
+			q.cond = p.cond
+		}
+		q.orders = p.orders
+	}
+	return q
+}
+
+// read models of query set into new slice, return the struct values.
+func (o *querySet) prefetchRead(q *querySet) ([]reflect.Value, error) {
+	container := reflect.New(reflect.SliceOf(reflect.PtrTo(q.mi.addrField.Elem().Type())))
+	if _, err := q.All(container.Interface()); err != nil {
+		return nil, err
+	}
+
+	ind := container.Elem()
+	rows := make([]reflect.Value, ind.Len())
+	for i := range rows {
+		rows[i] = ind.Index(i).Elem()
+	}
+	return rows, nil
+}
+
+// load one relation of models by one query, m2m relation need another query of through model.
+// return the loaded related models.
+func (o *querySet) prefetchField(mi *modelInfo, fi *fieldInfo, models []reflect.Value, p *Prefetch) ([]reflect.Value, error) {
+	rmi := fi.relModelInfo
+	q := o.prefetchQs(rmi, p)
+
+	// related rows grouped by key of model
+	groups := make(map[string][]reflect.Value)
+	var keys []interface{}
+	var rows []reflect.Value
+	var err error
+
+	switch {
+	case fi.fieldType == RelForeignKey || fi.fieldType == RelOneToOne:
+		for _, m := range models {
+			field := m.FieldByIndex(fi.fieldIndex)
+			if field.IsNil() {
+				continue
+			}
+			if _, v, ok := getExistPk(rmi, field.Elem()); ok {
+				keys = append(keys, v)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, nil
+		}
+		if rows, err = o.prefetchRead(q.Filter(rmi.fields.pk.name+ExprSep+"in", keys).(*querySet)); err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			_, v, _ := getExistPk(rmi, r)
+			groups[ToStr(v)] = append(groups[ToStr(v)], r)
+		}
+
+		for _, m := range models {
+			field := m.FieldByIndex(fi.fieldIndex)
+			if field.IsNil() {
+				continue
+			}
+			_, v, _ := getExistPk(rmi, field.Elem())
+			if rs := groups[ToStr(v)]; len(rs) > 0 {
+				field.Set(rs[0].Addr())
+			}
+		}
+		return rows, nil
+
+	case fi.fieldType == RelManyToMany || fi.fieldType == RelReverseMany && fi.reverseFieldInfo.mi.isThrough:
+		for _, m := range models {
+			if _, v, ok := getExistPk(mi, m); ok {
+				keys = append(keys, v)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, nil
+		}
+
+		// through model links model to related model
+		self, other := fi.reverseFieldInfo, fi.reverseFieldInfoTwo
+		tq := o.prefetchQs(fi.relThroughModelInfo, nil)
+		var links []ParamsList
+		if _, err = tq.Filter(self.name+ExprSep+"in", keys).ValuesList(&links, self.name, other.name); err != nil {
+			return nil, err
+		}
+		if len(links) == 0 {
+			break
+		}
+
+		owners := make(map[string][]string)
+		ids := make([]interface{}, 0, len(links))
+		for _, l := range links {
+			k := ToStr(l[1])
+			if _, ok := owners[k]; !ok {
+				ids = append(ids, l[1])
+			}
+			owners[k] = append(owners[k], ToStr(l[0]))
+		}
+
+		if rows, err = o.prefetchRead(q.Filter(rmi.fields.pk.name+ExprSep+"in", ids).(*querySet)); err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			_, v, _ := getExistPk(rmi, r)
+			for _, owner := range owners[ToStr(v)] {
+				groups[owner] = append(groups[owner], r)
+			}
+		}
+
+	case fi.fieldType == RelReverseOne || fi.fieldType == RelReverseMany:
+		for _, m := range models {
+			if _, v, ok := getExistPk(mi, m); ok {
+				keys = append(keys, v)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, nil
+		}
+
+		fk := fi.reverseFieldInfo
+		if rows, err = o.prefetchRead(q.Filter(fk.name+ExprSep+"in", keys).(*querySet)); err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			field := r.FieldByIndex(fk.fieldIndex)
+			if field.IsNil() {
+				continue
+			}
+			_, v, _ := getExistPk(mi, field.Elem())
+			groups[ToStr(v)] = append(groups[ToStr(v)], r)
+		}
+	}
+
+	// assign related models to reverse or m2m field
+	for _, m := range models {
+		_, v, _ := getExistPk(mi, m)
+		rs := groups[ToStr(v)]
+		field := m.FieldByIndex(fi.fieldIndex)
+
+		if field.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(field.Type(), 0, len(rs))
+			for _, r := range rs {
+				if field.Type().Elem().Kind() == reflect.Ptr {
+					slice = reflect.Append(slice, r.Addr())
+				} else {
+					slice = reflect.Append(slice, r)
+				}
+			}
+			field.Set(slice)
+		} else if len(rs) > 0 {
+			field.Set(rs[0].Addr())
+		}
+	}
+	return rows, nil
+}
+
+// check relation path can be loaded by join of RelatedSel.
+func (o *querySet) canJoin(path string) bool {
+	mi := o.mi
+	for _, ex := range strings.Split(path, ExprSep) {
+		fi, ok := mi.fields.GetByAny(ex)
+		if !ok || !fi.rel || fi.fieldType == RelManyToMany {
+			return false
+		}
+		mi = fi.relModelInfo
+	}
+	return true
+}
@@ -96,7 +96,11 @@ func (o *queryM2M) Add(mds ...interface{}) (int64, error) {
 	}
 	names = append(names, otherNames...)
 	values = append(values, otherValues...)
-	return dbase.InsertValue(orm.db, mi, true, names, values)
+	num, err := dbase.InsertValue(orm.db, mi, true, names, values)
+	if err == nil {
+		orm.invalidate(mi.table)
+	}
+	return num, err
 }
 
 // remove models following the origin model relationship
@@ -17,6 +17,8 @@ package orm
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"time"
 )
 
 type colValue struct {
@@ -56,19 +58,29 @@ func ColValue(opt operator, value interface{}) interface{} {
 
 // real query struct
 type querySet struct {
-	mi         *modelInfo
-	cond       *Condition
-	related    []string
-	relDepth   int
-	limit      int64
-	offset     int64
-	groups     []string
-	orders     []string
-	distinct   bool
-	forupdate  bool
-	orm        *orm
-	ctx        context.Context
-	forContext bool
+	mi           *modelInfo
+	cond         *Condition
+	related      []string
+	relDepth     int
+	prefetch     []*Prefetch
+	limit        int64
+	offset       int64
+	groups       []string
+	orders       []string
+	distinct     bool
+	only         []string
+	forupdate    bool
+	trashed      int
+	unscoped     bool
+	cursor       *Cursor
+	cursorBefore bool
+	cursorErr    error
+	annotations  []*Aggregation
+	having       *Condition
+	cache        *queryCache
+	orm          *orm
+	ctx          context.Context
+	forContext   bool
 }
 
 var _ QuerySeter = new(querySet)
@@ -140,12 +152,94 @@ func (o querySet) Distinct() QuerySeter {
 	return &o
 }
 
+// select only the fields when reading models.
+func (o querySet) Only(fields ...string) QuerySeter {
+	o.only = fields
+	return &o
+}
+
 // add FOR UPDATE to SELECT
 func (o querySet) ForUpdate() QuerySeter {
 	o.forupdate = true
 	return &o
 }
 
+// add aggregations to select, used by Aggregate.
+func (o querySet) Annotate(aggs ...*Aggregation) QuerySeter {
+	o.annotations = append(o.annotations[:len(o.annotations):len(o.annotations)], aggs...)
+	return &o
+}
+
+// set HAVING condition, expression can use name of annotated aggregation.
+func (o querySet) Having(cond *Condition) QuerySeter {
+	o.having = cond
+	return &o
+}
+
+// read rows after the cursor in current ordering.
+func (o querySet) After(cursor string) QuerySeter {
+	o.cursor, o.cursorErr = DecodeCursor(cursor)
+	o.cursorBefore = false
+	return &o
+}
+
+// read rows before the cursor in current ordering.
+func (o querySet) Before(cursor string) QuerySeter {
+	o.cursor, o.cursorErr = DecodeCursor(cursor)
+	o.cursorBefore = true
+	return &o
+}
+
+// return cursor of md used to read the next page.
+func (o *querySet) NextCursor(md interface{}) (string, error) {
+	return o.cursorOf(md, false)
+}
+
+// return cursor of md used to read the previous page.
+func (o *querySet) PrevCursor(md interface{}) (string, error) {
+	return o.cursorOf(md, true)
+}
+
+// read from primary database even replicas are registered.
+func (o querySet) ForcePrimary() QuerySeter {
+	o.orm = o.orm.ForcePrimary().(*orm)
+	return &o
+}
+
+// get dbQuerier for read query, FOR UPDATE query stays on primary.
+func (o *querySet) readDB() dbQuerier {
+	if o.forupdate {
+		return o.orm.db
+	}
+	return o.orm.readDB()
+}
+
+// include soft deleted rows and delete rows permanently.
+func (o querySet) Unscoped() QuerySeter {
+	o.trashed = trashedWith
+	o.unscoped = true
+	return &o
+}
+
+// include soft deleted rows.
+func (o querySet) WithTrashed() QuerySeter {
+	o.trashed = trashedWith
+	return &o
+}
+
+// only soft deleted rows.
+func (o querySet) OnlyTrashed() QuerySeter {
+	o.trashed = trashedOnly
+	return &o
+}
+
+// cache results of All, One, Count and Values for ttl.
+// cached results are invalidated when the queried tables are written by orm.
+func (o querySet) Cache(ttl time.Duration) QuerySeter {
+	o.cache = &queryCache{alias: o.orm.alias.Name, ttl: ttl}
+	return &o
+}
+
 // set relation model to query together.
 // it will query relation models and assign to parent model.
 func (o querySet) RelatedSel(params ...interface{}) QuerySeter {
@@ -166,6 +260,21 @@ func (o querySet) RelatedSel(params ...interface{}) QuerySeter {
 	return &o
 }
 
+// set relations loaded by separate query after All and One.
+func (o querySet) Prefetch(params ...interface{}) QuerySeter {
+	for _, p := range params {
+		switch val := p.(type) {
+		case string:
+			o.prefetch = append(o.prefetch, NewPrefetch(val))
+		case *Prefetch:
+			o.prefetch = append(o.prefetch, val)
+		default:
+			panic(fmt.Errorf("<QuerySeter.Prefetch> wrong param kind: %v", val))
+		}
+	}
+	return &o
+}
+
 // set condition to QuerySeter.
 func (o querySet) SetCond(cond *Condition) QuerySeter {
 	o.cond = cond
@@ -179,23 +288,49 @@ func (o querySet) GetCond() *Condition {
 
 // return QuerySeter execution result number
 func (o *querySet) Count() (int64, error) {
-	return o.orm.alias.DbBaser.Count(o.orm.db, o, o.mi, o.cond, o.orm.alias.TZ)
+	return o.orm.alias.DbBaser.Count(o.readDB(), o, o.mi, o.cond, o.orm.alias.TZ)
 }
 
 // check result empty or not after QuerySeter executed
 func (o *querySet) Exist() bool {
-	cnt, _ := o.orm.alias.DbBaser.Count(o.orm.db, o, o.mi, o.cond, o.orm.alias.TZ)
+	cnt, _ := o.orm.alias.DbBaser.Count(o.readDB(), o, o.mi, o.cond, o.orm.alias.TZ)
 	return cnt > 0
 }
 
 // execute update with parameters
 func (o *querySet) Update(values Params) (int64, error) {
-	return o.orm.alias.DbBaser.UpdateBatch(o.orm.db, o, o.mi, o.cond, values, o.orm.alias.TZ)
+	num, err := o.orm.alias.DbBaser.UpdateBatch(o.orm.db, o, o.mi, o.cond, values, o.orm.alias.TZ)
+	if err == nil {
+		o.orm.invalidate(o.mi.table)
+	}
+	return num, err
 }
 
 // execute delete
 func (o *querySet) Delete() (int64, error) {
-	return o.orm.alias.DbBaser.DeleteBatch(o.orm.db, o, o.mi, o.cond, o.orm.alias.TZ)
+	if fi := o.mi.fields.softDelete; fi != nil && !o.unscoped {
+		if o.cond == nil || o.cond.IsEmpty() {
+			panic(fmt.Errorf("delete operation cannot execute without condition"))
+		}
+		tnow := time.Now()
+		o.orm.alias.DbBaser.TimeToDB(&tnow, o.orm.alias.TZ)
+		return o.Update(Params{fi.column: tnow})
+	}
+	return o.ForceDelete()
+}
+
+// delete matched rows permanently, soft_delete field is ignored.
+// soft deleted rows are matched too, unless OnlyTrashed is used.
+func (o *querySet) ForceDelete() (int64, error) {
+	q := *o
+	if q.trashed == trashedWithout {
+		q.trashed = trashedWith
+	}
+	num, err := o.orm.alias.DbBaser.DeleteBatch(o.orm.db, &q, o.mi, o.cond, o.orm.alias.TZ)
+	if err == nil {
+		o.orm.invalidate(deleteTables(o.mi)...)
+	}
+	return num, err
 }
 
 // return a insert queryer.
@@ -210,14 +345,35 @@ func (o *querySet) PrepareInsert() (Inserter, error) {
 // query all data and map to containers.
 // cols means the columns when querying.
 func (o *querySet) All(container interface{}, cols ...string) (int64, error) {
-	return o.orm.alias.DbBaser.ReadBatch(o.orm.db, o, o.mi, o.cond, container, o.orm.alias.TZ, cols)
+	if o.cursorErr != nil {
+		return 0, o.cursorErr
+	}
+	qs, cond, err := o.cursorQuery()
+	if err != nil {
+		return 0, err
+	}
+	num, err := o.orm.alias.DbBaser.ReadBatch(o.readDB(), qs, o.mi, cond, container, o.orm.alias.TZ, cols)
+	if err == nil && o.cursorBefore {
+		reverseContainer(container)
+	}
+	if err == nil && num > 0 && len(o.prefetch) > 0 {
+		err = o.prefetchRelated(container)
+	}
+	return num, err
 }
 
 // query one row data and map to containers.
 // cols means the columns when querying.
 func (o *querySet) One(container interface{}, cols ...string) error {
+	if o.cursorErr != nil {
+		return o.cursorErr
+	}
 	o.limit = 1
-	num, err := o.orm.alias.DbBaser.ReadBatch(o.orm.db, o, o.mi, o.cond, container, o.orm.alias.TZ, cols)
+	qs, cond, err := o.cursorQuery()
+	if err != nil {
+		return err
+	}
+	num, err := o.orm.alias.DbBaser.ReadBatch(o.readDB(), qs, o.mi, cond, container, o.orm.alias.TZ, cols)
 	if err != nil {
 		return err
 	}
@@ -228,26 +384,110 @@ func (o *querySet) One(container interface{}, cols ...string) error {
 	if num > 1 {
 		return ErrMultiRows
 	}
+	if len(o.prefetch) > 0 {
+		return o.prefetchRelated(container)
+	}
 	return nil
 }
 
+// read rows one by one into new model and call fn with the pointer of model.
+// rows are not limited by DefaultRowsLimit unless Limit is set.
+func (o *querySet) Iterate(fn func(md interface{}) error) (int64, error) {
+	if o.cursorErr != nil {
+		return 0, o.cursorErr
+	}
+	qs, cond, err := o.cursorQuery()
+	if err != nil {
+		return 0, err
+	}
+	if qs.limit == 0 {
+		q := *qs
+		q.limit = -1
+		qs = &q
+	}
+	return o.orm.alias.DbBaser.ReadIter(o.readDB(), qs, o.mi, cond, o.orm.alias.TZ, fn)
+}
+
+// read rows in chunks of size and call fn with slice of model pointers, e.g. []*User.
+// prefetched relations are loaded for every chunk.
+func (o *querySet) IterateBatch(size int, fn func(container interface{}) error) (int64, error) {
+	if size <= 0 {
+		panic(fmt.Errorf("<QuerySeter.IterateBatch> size must be greater than 0"))
+	}
+
+	typ := reflect.SliceOf(o.mi.addrField.Type())
+	chunk := reflect.MakeSlice(typ, 0, size)
+	flush := func() error {
+		container := reflect.New(typ)
+		container.Elem().Set(chunk)
+		if len(o.prefetch) > 0 {
+			if err := o.prefetchRelated(container.Interface()); err != nil {
+				return err
+			}
+		}
+		chunk = reflect.MakeSlice(typ, 0, size)
+		return fn(container.Elem().Interface())
+	}
+
+	num, err := o.Iterate(func(md interface{}) error {
+		chunk = reflect.Append(chunk, reflect.ValueOf(md))
+		if chunk.Len() < size {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && chunk.Len() > 0 {
+		err = flush()
+	}
+	return num, err
+}
+
+// query annotated aggregations grouped by GroupBy fields and map to container.
+// container can be *[]Params, *[]ParamsList or pointer to struct slice,
+// exprs are the grouped fields to select, default is GroupBy fields.
+func (o *querySet) Aggregate(container interface{}, exprs ...string) (int64, error) {
+	query, args := getAggregateSQL(o.orm.alias.DbBaser, o, o.cond, exprs, o.orm.alias.TZ)
+	rs := newRawSet(o.orm, query, args).(*rawSet)
+	switch container.(type) {
+	case *[]Params, *[]ParamsList:
+		return rs.readValues(container, nil)
+	}
+	return rs.QueryRows(container)
+}
+
 // query all data and map to []map[string]interface.
 // expres means condition expression.
 // it converts data to []map[column]value.
 func (o *querySet) Values(results *[]Params, exprs ...string) (int64, error) {
-	return o.orm.alias.DbBaser.ReadValues(o.orm.db, o, o.mi, o.cond, exprs, results, o.orm.alias.TZ)
+	return o.readValues(exprs, results)
 }
 
 // query all data and map to [][]interface
 // it converts data to [][column_index]value
 func (o *querySet) ValuesList(results *[]ParamsList, exprs ...string) (int64, error) {
-	return o.orm.alias.DbBaser.ReadValues(o.orm.db, o, o.mi, o.cond, exprs, results, o.orm.alias.TZ)
+	return o.readValues(exprs, results)
 }
 
 // query all data and map to []interface.
 // it's designed for one row record set, auto change to []value, not [][column]value.
 func (o *querySet) ValuesFlat(result *ParamsList, expr string) (int64, error) {
-	return o.orm.alias.DbBaser.ReadValues(o.orm.db, o, o.mi, o.cond, []string{expr}, result, o.orm.alias.TZ)
+	return o.readValues([]string{expr}, result)
+}
+
+// read values into container, keyset pagination is applied.
+func (o *querySet) readValues(exprs []string, container interface{}) (int64, error) {
+	if o.cursorErr != nil {
+		return 0, o.cursorErr
+	}
+	qs, cond, err := o.cursorQuery()
+	if err != nil {
+		return 0, err
+	}
+	num, err := o.orm.alias.DbBaser.ReadValues(o.readDB(), qs, o.mi, cond, exprs, container, o.orm.alias.TZ)
+	if err == nil && o.cursorBefore {
+		reverseContainer(container)
+	}
+	return num, err
 }
 
 // query all rows into map[string]interface with specify key and value column name.
@@ -282,9 +522,15 @@ func (o *querySet) RowsToStruct(ptrStruct interface{}, keyCol, valueCol string)
 func (o querySet) WithContext(ctx context.Context) QuerySeter {
 	o.ctx = ctx
 	o.forContext = true
+	o.orm = o.orm.WithContext(ctx).(*orm)
 	return &o
 }
 
+// return sub query selecting the field expression, used as condition value.
+func (o querySet) ValuesExpr(expr string) *SubQuery {
+	return &SubQuery{qs: &o, expr: expr}
+}
+
 // add condition expression to QuerySeter.
 func (o querySet) Search(expr string, fields ...string) QuerySeter {
 	if o.cond == nil {
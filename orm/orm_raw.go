@@ -15,6 +15,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -51,11 +52,7 @@ func newRawPreparer(rs *rawSet) (RawPreparer, error) {
 	if err != nil {
 		return nil, err
 	}
-	if Debug {
-		o.stmt = newStmtQueryLog(rs.orm.alias, st, query)
-	} else {
-		o.stmt = st
-	}
+	o.stmt = wrapStmtQuerier(rs.orm.alias, rs.orm.interceptors, st, query)
 	return o, nil
 }
 
@@ -63,6 +60,7 @@ func newRawPreparer(rs *rawSet) (RawPreparer, error) {
 type rawSet struct {
 	query string
 	args  []interface{}
+	cache *queryCache
 	orm   *orm
 }
 
@@ -74,6 +72,13 @@ func (o rawSet) SetArgs(args ...interface{}) RawSeter {
 	return &o
 }
 
+// cache results of query for ttl, tables are the tables of query
+// which invalidate the results when written by orm.
+func (o rawSet) Cache(ttl time.Duration, tables ...string) RawSeter {
+	o.cache = &queryCache{alias: o.orm.alias.Name, ttl: ttl, tables: tables}
+	return &o
+}
+
 // execute raw sql and return sql.Result
 func (o *rawSet) Exec() (sql.Result, error) {
 	query := o.query
@@ -319,7 +324,7 @@ func (o *rawSet) QueryRow(containers ...interface{}) error {
 	o.orm.alias.DbBaser.ReplaceMarks(&query)
 
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
-	rows, err := o.orm.db.Query(query, args...)
+	rows, err := queryCached(nil, o.orm.db, o.queryCache(), query, args)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return ErrNoRows
@@ -404,6 +409,11 @@ func (o *rawSet) QueryRow(containers ...interface{}) error {
 		return ErrNoRows
 	}
 
+	// read to the end so the row is cached
+	if o.queryCache() != nil {
+		for rows.Next() {
+		}
+	}
 	return nil
 }
 
@@ -452,7 +462,7 @@ func (o *rawSet) QueryRows(containers ...interface{}) (int64, error) {
 	o.orm.alias.DbBaser.ReplaceMarks(&query)
 
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
-	rows, err := o.orm.db.Query(query, args...)
+	rows, err := queryCached(nil, o.orm.readDB(), o.queryCache(), query, args)
 	if err != nil {
 		return 0, err
 	}
@@ -598,8 +608,7 @@ func (o *rawSet) readValues(container interface{}, needCols []string) (int64, er
 
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
 
-	var rs *sql.Rows
-	rs, err := o.orm.db.Query(query, args...)
+	rs, err := queryCached(nil, o.orm.db, o.queryCache(), query, args)
 	if err != nil {
 		return 0, err
 	}
@@ -725,7 +734,7 @@ func (o *rawSet) queryRowsTo(container interface{}, keyCol, valueCol string) (in
 
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
 
-	rs, err := o.orm.db.Query(query, args...)
+	rs, err := queryCached(nil, o.orm.db, o.queryCache(), query, args)
 	if err != nil {
 		return 0, err
 	}
@@ -858,6 +867,13 @@ func (o *rawSet) Prepare() (RawPreparer, error) {
 	return newRawPreparer(o)
 }
 
+// set context to RawSeter.
+func (o rawSet) WithContext(ctx context.Context) RawSeter {
+	o.orm = o.orm.WithContext(ctx).(*orm)
+	return &o
+}
+
+// create new RawSeter.
 func newRawSet(orm *orm, query string, args []interface{}) RawSeter {
 	o := new(rawSet)
 	o.query = query
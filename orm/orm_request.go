@@ -5,8 +5,10 @@
 package orm
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
 	"net/url"
+	"reflect"
 	"strings"
 
 	"github.com/raryanda/go/utility"
@@ -20,6 +22,8 @@ type RequestQuery struct {
 	Embeds     []string
 	Offset     int
 	Limit      int
+	Cursor     string
+	Policy     *RequestPolicy
 }
 
 // Query make new query setter based on request query.
@@ -29,6 +33,14 @@ func (rq *RequestQuery) Query(model interface{}) (QuerySeter, Ormer) {
 	return rq.Apply(o.QueryTable(model)), o
 }
 
+// QueryContext make new query setter based on request query with ormer bound to ctx,
+// queries are counted into QueryStats of ctx, such as the request context.
+func (rq *RequestQuery) QueryContext(ctx context.Context, model interface{}) (QuerySeter, Ormer) {
+	o := NewOrm().WithContext(ctx)
+
+	return rq.Apply(o.QueryTable(model)), o
+}
+
 // ExcludeEmbeds will exclude RequestQuery Embeds in parameter
 // example: bool:=rq.ExcludeEmbeds("table_name field")
 func (rq *RequestQuery) ExcludeEmbeds(customEmbeds string) bool {
@@ -47,33 +59,129 @@ func (rq *RequestQuery) Apply(qs QuerySeter) QuerySeter {
 	// apply conditions
 	qs = qs.SetCond(rq.GetCondition())
 
-	// apply embeds
-	if len(rq.Embeds) > 0 {
-		j := rq.GetJoin()
-		qs = qs.RelatedSel(j...)
+	// apply embeds, forward relations are joined and the others are prefetched
+	var joins, prefetch []interface{}
+	for _, j := range rq.GetJoin() {
+		if q, ok := qs.(*querySet); ok && !q.canJoin(j.(string)) {
+			prefetch = append(prefetch, j)
+		} else {
+			joins = append(joins, j)
+		}
+	}
+	if len(joins) > 0 {
+		qs = qs.RelatedSel(joins...)
+	}
+	if len(prefetch) > 0 {
+		qs = qs.Prefetch(prefetch...)
+	}
+
+	// apply sparse fields
+	if len(rq.Fields) > 0 {
+		qs = qs.Only(rq.Fields...)
 	}
 
 	// apply order by
-	qs = qs.OrderBy(rq.OrderBy...)
+	orders := make([]string, 0, len(rq.OrderBy))
+	for _, order := range rq.OrderBy {
+		if rq.Policy.allowOrder(order) {
+			orders = append(orders, order)
+		}
+	}
+	qs = qs.OrderBy(orders...)
 
 	// apply limit
-	qs = qs.Limit(rq.Limit, rq.Offset)
+	qs = qs.Limit(rq.Policy.limit(rq.Limit), rq.Offset)
+
+	// apply cursor
+	if rq.Cursor != "" {
+		if c, err := DecodeCursor(rq.Cursor); err == nil && c.Before {
+			qs = qs.Before(rq.Cursor)
+		} else {
+			qs = qs.After(rq.Cursor)
+		}
+	}
 
 	return qs
 }
 
-// ReadFromContext reading from params url
+// Cursors return the cursors of next and previous page,
+// container is the result read by query setter applied with request query.
+// example: c.ResponseBody.NextCursor, c.ResponseBody.PrevCursor = rq.Cursors(qs, &data)
+func (rq *RequestQuery) Cursors(qs QuerySeter, container interface{}) (next string, prev string) {
+	ind := reflect.Indirect(reflect.ValueOf(container))
+	if ind.Kind() != reflect.Slice || ind.Len() == 0 {
+		return
+	}
+
+	var before bool
+	if rq.Cursor != "" {
+		if c, err := DecodeCursor(rq.Cursor); err == nil {
+			before = c.Before
+		}
+	}
+	limit := rq.Policy.limit(rq.Limit)
+	full := limit > 0 && ind.Len() >= limit
+
+	item := func(i int) interface{} {
+		v := ind.Index(i)
+		if v.Kind() != reflect.Ptr {
+			return v.Addr().Interface()
+		}
+		return v.Interface()
+	}
+
+	if full || before {
+		next, _ = qs.NextCursor(item(ind.Len() - 1))
+	}
+	if full && before || !before && rq.Cursor != "" {
+		prev, _ = qs.PrevCursor(item(0))
+	}
+	return
+}
+
+// Sparse return data with only the requested fields for JSON output,
+// data is the model or models read by query setter applied with request query.
+// example: c.ResponseBody.Data = rq.Sparse(data)
+func (rq *RequestQuery) Sparse(data interface{}) interface{} {
+	if len(rq.Fields) == 0 {
+		return data
+	}
+
+	ind := reflect.Indirect(reflect.ValueOf(data))
+	switch ind.Kind() {
+	case reflect.Slice:
+		res := make([]interface{}, ind.Len())
+		for i := range res {
+			res[i] = sparseValue(ind.Index(i), rq.Fields)
+		}
+		return res
+	case reflect.Struct:
+		return sparseValue(ind, rq.Fields)
+	}
+	return data
+}
+
+// ReadFromContext reading from params url,
+// when Policy is set, parameters rejected by policy are reported by Validate.
+// example:
+//	rq := &orm.RequestQuery{Policy: policy}
+//	if err := rq.ReadFromContext(c.QueryParams()).Validate(); err != nil {
+//		return c.Serve(err)
+//	}
 func (rq *RequestQuery) ReadFromContext(params url.Values) *RequestQuery {
 	if pl := utility.ToInt(params.Get("limit")); pl != 0 {
 		rq.Limit = pl
 	}
 
-	if pp := utility.ToInt(params.Get("page")); pp != 0 {
+	if pc := params.Get("cursor"); pc != "" {
+		rq.Cursor = pc
+	} else if pp := utility.ToInt(params.Get("page")); pp != 0 {
 		rq.Offset = rq.Limit * (pp - 1)
 	}
 
 	if pf := params.Get("fields"); pf != "" {
-		rq.Fields = strings.Split(pf, ",")
+		k := strings.Replace(pf, ".", "__", -1)
+		rq.Fields = strings.Split(k, ",")
 	}
 
 	if po := params.Get("orderby"); po != "" {
@@ -90,10 +198,9 @@ func (rq *RequestQuery) ReadFromContext(params url.Values) *RequestQuery {
 		for _, cond := range strings.Split(pc, "|") {
 			var bc = make(map[string]string)
 			for _, partcond := range strings.Split(cond, "%2C") {
-				kv := strings.Split(partcond, ":")
-				if len(kv) > 2 {
-					bc[kv[0]] = fmt.Sprintf("%s:%s:%s", kv[1], kv[2], kv[3])
-				} else if len(kv) == 2 {
+				// value may contain colon, such as time or regex
+				kv := strings.SplitN(partcond, ":", 2)
+				if len(kv) == 2 {
 					bc[kv[0]] = kv[1]
 				} else {
 					bc[partcond] = "true"
@@ -112,38 +219,46 @@ func (rq *RequestQuery) GetCondition() *Condition {
 	for _, q := range rq.Conditions {
 		cd := NewCondition()
 		for k, v := range q {
-			if strings.Contains(k, "AndNot.") {
-				k = strings.Replace(k, "AndNot.", "", -1)
-				k = strings.Replace(k, ".", "__", -1)
-
-				cd = rq.condition(cd, k, v, "andnot")
-			} else if strings.Contains(k, "Or.") {
-				k = strings.Replace(k, "Or.", "", -1)
-				k = strings.Replace(k, ".", "__", -1)
-				cd = rq.condition(cd, k, v, "or")
-			} else if strings.Contains(k, "OrNot.") {
-				k = strings.Replace(k, "OrNot.", "", -1)
-				k = strings.Replace(k, ".", "__", -1)
-
-				cd = rq.condition(cd, k, v, "ornot")
-			} else {
-				k = strings.Replace(k, "And.", "", -1)
-				k = strings.Replace(k, ".", "__", -1)
-
-				cd = rq.condition(cd, k, v, "and")
+			field, operator := conditionKey(k)
+			if !rq.Policy.allowFilter(field) {
+				// rejected by policy, reported by Validate
+				continue
 			}
+			cd = rq.condition(cd, field, v, operator)
+		}
+		if !cd.IsEmpty() {
+			c = c.AndCond(cd)
 		}
-		c = c.AndCond(cd)
 	}
 
 	return c
 }
 
+// split key of request condition into field expression and the logical operator.
+func conditionKey(k string) (field string, operator string) {
+	if strings.Contains(k, "AndNot.") {
+		k = strings.Replace(k, "AndNot.", "", -1)
+		operator = "andnot"
+	} else if strings.Contains(k, "Or.") {
+		k = strings.Replace(k, "Or.", "", -1)
+		operator = "or"
+	} else if strings.Contains(k, "OrNot.") {
+		k = strings.Replace(k, "OrNot.", "", -1)
+		operator = "ornot"
+	} else {
+		k = strings.Replace(k, "And.", "", -1)
+		operator = "and"
+	}
+	return strings.Replace(k, ".", "__", -1), operator
+}
+
 // GetJoin making join orm from request
 func (rq *RequestQuery) GetJoin() []interface{} {
-	new := make([]interface{}, len(rq.Embeds))
-	for i, v := range rq.Embeds {
-		new[i] = v
+	new := make([]interface{}, 0, len(rq.Embeds))
+	for _, v := range rq.Embeds {
+		if rq.Policy.allowEmbed(v) {
+			new = append(new, v)
+		}
 	}
 
 	return new
@@ -212,3 +327,68 @@ func (rq *RequestQuery) condition(c *Condition, field string, value string, oper
 	}
 	return
 }
+
+// keep only the fields of model in json object,
+// fields of related model are kept by path, e.g. "profile__age".
+func sparseValue(val reflect.Value, fields []string) interface{} {
+	ind := reflect.Indirect(val)
+	if ind.Kind() != reflect.Struct {
+		return val.Interface()
+	}
+	mi, ok := modelCache.getByFullName(getFullName(ind.Type()))
+	if !ok {
+		return val.Interface()
+	}
+
+	b, err := json.Marshal(val.Interface())
+	if err != nil {
+		return val.Interface()
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return val.Interface()
+	}
+
+	// group fields by the first name
+	var names []string
+	subs := make(map[string][]string)
+	for _, f := range fields {
+		exs := strings.SplitN(f, ExprSep, 2)
+		if _, ok := subs[exs[0]]; !ok {
+			names = append(names, exs[0])
+			subs[exs[0]] = nil
+		}
+		if len(exs) == 2 {
+			subs[exs[0]] = append(subs[exs[0]], exs[1])
+		}
+	}
+
+	res := make(map[string]interface{}, len(names)+1)
+	for _, name := range append([]string{mi.fields.pk.name}, names...) {
+		fi, ok := mi.fields.GetByAny(name)
+		if !ok {
+			continue
+		}
+		key := jsonKey(fi.sf)
+		if _, ok := raw[key]; !ok {
+			continue
+		}
+		if sub := subs[name]; len(sub) > 0 && fi.rel {
+			if field := ind.FieldByIndex(fi.fieldIndex); !field.IsNil() {
+				res[key] = sparseValue(field, sub)
+				continue
+			}
+		}
+		res[key] = raw[key]
+	}
+	return res
+}
+
+// get the json object key of struct field.
+func jsonKey(sf reflect.StructField) string {
+	tag := strings.Split(sf.Tag.Get("json"), ",")[0]
+	if tag == "" {
+		return sf.Name
+	}
+	return tag
+}
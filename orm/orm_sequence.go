@@ -0,0 +1,192 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SequenceReset is the period the counter of sequence restarts from 1.
+type SequenceReset int
+
+// Enum the SequenceReset
+const (
+	ResetNever SequenceReset = iota
+	ResetYearly
+	ResetMonthly
+	ResetDaily
+)
+
+// SequenceCounter is the counter of sequence in each period, stored in table orm_sequence.
+// register it with RegisterModel(new(orm.SequenceCounter)) before using Sequence.
+type SequenceCounter struct {
+	ID     int64
+	Name   string `orm:"size(100)"`
+	Period string `orm:"size(10)"`
+	Value  int64
+}
+
+// TableName of sequence counter.
+func (c *SequenceCounter) TableName() string {
+	return "orm_sequence"
+}
+
+// TableUnique of sequence counter, one counter for each period of sequence.
+func (c *SequenceCounter) TableUnique() [][]string {
+	return [][]string{{"Name", "Period"}}
+}
+
+// Sequence generates document numbers, such as invoice numbers, from counter increased atomically in database.
+// Format is the template of number, placeholders are:
+//	{yyyy}  year, e.g. 2019
+//	{yy}    year of two digits, e.g. 19
+//	{mm}    month of two digits, e.g. 07
+//	{dd}    day of two digits, e.g. 01
+//	{roman} month in roman numeral, e.g. VII
+//	{n}     counter, {n:3} is zero-padded to 3 digits, e.g. 001
+// for example:
+//	seq := &orm.Sequence{Name: "sales_order", Format: "SO/{yyyy}/{roman}/{n:3}", Reset: orm.ResetMonthly}
+//	code, err := seq.Next(o) // SO/2019/VII/001
+type Sequence struct {
+	Name   string
+	Format string
+	Reset  SequenceReset
+	// gapless sequence increases the counter in transaction of caller and keeps the row locked until
+	// it ends, the number is reused when the transaction is rolled back.
+	// otherwise the counter is increased in its own transaction, concurrent callers are not blocked
+	// but numbers of rolled back transactions are skipped.
+	Gapless bool
+}
+
+var sequencePlaceholder = regexp.MustCompile(`\{(\w+)(?::(\d+))?\}`)
+
+// Next get the next number of sequence at current time.
+func (s *Sequence) Next(o Ormer) (string, error) {
+	return s.NextAt(o, time.Now())
+}
+
+// NextAt get the next number of sequence in period of t.
+func (s *Sequence) NextAt(o Ormer, t time.Time) (string, error) {
+	oo, ok := o.(*orm)
+	if !ok {
+		return "", fmt.Errorf("<Sequence.Next> unsupported ormer `%T`", o)
+	}
+
+	var n int64
+	inc := func(o Ormer) (err error) {
+		n, err = increaseCounter(o.(*orm), s.Name, s.period(t))
+		return
+	}
+
+	var err error
+	switch {
+	case s.Gapless:
+		if !oo.isTx {
+			return "", fmt.Errorf("<Sequence.Next> gapless sequence `%s` must be used in transaction", s.Name)
+		}
+		err = inc(oo)
+	case oo.isTx:
+		// counter is not locked until the transaction of caller ends
+		no := new(orm)
+		no.ctx = oo.ctx
+		if err = no.Using(oo.alias.Name); err != nil {
+			return "", err
+		}
+		err = no.Transaction(inc)
+	default:
+		err = oo.Transaction(inc)
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.format(t, n)
+}
+
+// get the period of counter at t.
+func (s *Sequence) period(t time.Time) string {
+	switch s.Reset {
+	case ResetYearly:
+		return t.Format("2006")
+	case ResetMonthly:
+		return t.Format("2006-01")
+	case ResetDaily:
+		return t.Format("2006-01-02")
+	}
+	return ""
+}
+
+// format number n at t by template of sequence.
+func (s *Sequence) format(t time.Time, n int64) (string, error) {
+	format := s.Format
+	if format == "" {
+		format = "{n}"
+	}
+
+	var err error
+	code := sequencePlaceholder.ReplaceAllStringFunc(format, func(m string) string {
+		sub := sequencePlaceholder.FindStringSubmatch(m)
+		switch sub[1] {
+		case "yyyy":
+			return t.Format("2006")
+		case "yy":
+			return t.Format("06")
+		case "mm":
+			return t.Format("01")
+		case "dd":
+			return t.Format("02")
+		case "roman":
+			return roman[t.Month()]
+		case "n":
+			num := strconv.FormatInt(n, 10)
+			if width, _ := strconv.Atoi(sub[2]); len(num) < width {
+				num = strings.Repeat("0", width-len(num)) + num
+			}
+			return num
+		}
+		err = fmt.Errorf("<Sequence.Next> unknown placeholder `%s` of sequence `%s`", m, s.Name)
+		return m
+	})
+	return code, err
+}
+
+// increase counter of sequence in period, the counter row is locked until transaction of o ends.
+// the counter is created for the first number of period.
+func increaseCounter(o *orm, name, period string) (int64, error) {
+	qs := o.QueryTable(new(SequenceCounter)).Filter("name", name).Filter("period", period)
+	var err error
+	for i := 0; i < 2; i++ {
+		var num int64
+		num, err = qs.Update(Params{"value": ColValue(ColAdd, 1)})
+		if err != nil {
+			return 0, err
+		}
+		if num > 0 {
+			var c SequenceCounter
+			if err := qs.One(&c, "Value"); err != nil {
+				return 0, err
+			}
+			return c.Value, nil
+		}
+
+		// concurrent insert of the same counter is failed by unique key, increase it again.
+		insert := func(o Ormer) error {
+			_, err := o.Insert(&SequenceCounter{Name: name, Period: period, Value: 1})
+			return err
+		}
+		if o.alias.DbBaser.SupportSavepoint() {
+			err = o.savepoint(insert)
+		} else {
+			err = insert(o)
+		}
+		if err == nil {
+			return 1, nil
+		}
+	}
+	return 0, fmt.Errorf("<Sequence.Next> cannot increase counter of sequence `%s`, %s", name, err)
+}
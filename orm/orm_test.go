@@ -20,16 +20,25 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/raryanda/go/cache"
+	"github.com/raryanda/go/validation"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 var _ = os.PathSeparator
@@ -197,6 +206,14 @@ func TestSyncDb(t *testing.T) {
 	RegisterModel(new(IntegerPk))
 	RegisterModel(new(UintPk))
 	RegisterModel(new(PtrPk))
+	RegisterModel(new(Hook))
+	RegisterModel(new(Trash))
+	RegisterModel(new(Versioned))
+	RegisterModel(new(Tracked))
+	RegisterModel(new(SequenceCounter))
+	RegisterModel(new(Document))
+	RegisterModel(new(Account))
+	RegisterModel(new(AccountMember))
 
 	err := RunSyncdb("default", true, Debug)
 	throwFail(t, err)
@@ -221,6 +238,14 @@ func TestRegisterModels(t *testing.T) {
 	RegisterModel(new(IntegerPk))
 	RegisterModel(new(UintPk))
 	RegisterModel(new(PtrPk))
+	RegisterModel(new(Hook))
+	RegisterModel(new(Trash))
+	RegisterModel(new(Versioned))
+	RegisterModel(new(Tracked))
+	RegisterModel(new(SequenceCounter))
+	RegisterModel(new(Document))
+	RegisterModel(new(Account))
+	RegisterModel(new(AccountMember))
 
 	BootStrap()
 
@@ -887,6 +912,14 @@ func TestOperators(t *testing.T) {
 	throwFail(t, err)
 	throwFail(t, AssertIs(num, 1))
 
+	num, err = qs.Filter("profile__isnull", false).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+
+	num, err = qs.Filter("user_name__isnull", false).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 3))
+
 	num, err = qs.Filter("status__in", 1, 2).Count()
 	throwFail(t, err)
 	throwFail(t, AssertIs(num, 2))
@@ -2129,237 +2162,1210 @@ func TestBeginTxWithContextCanceled(t *testing.T) {
 	throwFail(t, AssertIs(err, context.Canceled))
 }
 
-func TestReadOrCreate(t *testing.T) {
-	u := &User{
-		UserName: "Kyle",
-		Email:    "kylemcc@gmail.com",
-		Password: "other_pass",
-		Status:   7,
-		IsStaff:  false,
-		IsActive: true,
-	}
+func TestWithContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	created, pk, err := dORM.ReadOrCreate(u, "UserName")
-	throwFail(t, err)
-	throwFail(t, AssertIs(created, true))
-	throwFail(t, AssertIs(u.ID, pk))
-	throwFail(t, AssertIs(u.UserName, "Kyle"))
-	throwFail(t, AssertIs(u.Email, "kylemcc@gmail.com"))
-	throwFail(t, AssertIs(u.Password, "other_pass"))
-	throwFail(t, AssertIs(u.Status, 7))
-	throwFail(t, AssertIs(u.IsStaff, false))
-	throwFail(t, AssertIs(u.IsActive, true))
-	throwFail(t, AssertIs(u.Created.In(DefaultTimeLoc), u.Created.In(DefaultTimeLoc), testDate))
-	throwFail(t, AssertIs(u.Updated.In(DefaultTimeLoc), u.Updated.In(DefaultTimeLoc), testDateTime))
+	o := NewOrm().WithContext(ctx)
+	user := User{UserName: "slene"}
+	err := o.Read(&user, "UserName")
+	throwFail(t, AssertIs(err, context.Canceled))
 
-	nu := &User{UserName: u.UserName, Email: "someotheremail@gmail.com"}
-	created, pk, err = dORM.ReadOrCreate(nu, "UserName")
+	var users []*User
+	_, err = dORM.QueryTable("user").WithContext(ctx).All(&users)
+	throwFail(t, AssertIs(err, context.Canceled))
+
+	var name string
+	Q := dDbBaser.TableQuote()
+	query := fmt.Sprintf("SELECT %suser_name%s FROM %suser%s", Q, Q, Q, Q)
+	err = dORM.Raw(query).WithContext(ctx).QueryRow(&name)
+	throwFail(t, AssertIs(err, context.Canceled))
+
+	err = o.Begin()
+	throwFail(t, AssertIs(err, context.Canceled))
+
+	err = dORM.Read(&user, "UserName")
 	throwFail(t, err)
-	throwFail(t, AssertIs(created, false))
-	throwFail(t, AssertIs(nu.ID, u.ID))
-	throwFail(t, AssertIs(pk, u.ID))
-	throwFail(t, AssertIs(nu.UserName, u.UserName))
-	throwFail(t, AssertIs(nu.Email, u.Email)) // should contain the value in the table, not the one specified above
-	throwFail(t, AssertIs(nu.Password, u.Password))
-	throwFail(t, AssertIs(nu.Status, u.Status))
-	throwFail(t, AssertIs(nu.IsStaff, u.IsStaff))
-	throwFail(t, AssertIs(nu.IsActive, u.IsActive))
+}
 
-	dORM.Delete(u)
+// get the querier wrapped by logger, interceptors and context.
+func baseDbQuerier(db dbQuerier) dbQuerier {
+	switch d := db.(type) {
+	case *dbQueryCtx:
+		return baseDbQuerier(d.db)
+	case *dbQueryLog:
+		return baseDbQuerier(d.db)
+	case *dbQueryIntercept:
+		return baseDbQuerier(d.db)
+	}
+	return db
 }
 
-func TestInLine(t *testing.T) {
-	name := "inline"
-	email := "hello@go.com"
-	inline := NewInLine()
-	inline.Name = name
-	inline.Email = email
+func TestWithContextTransaction(t *testing.T) {
+	// wrap querier by logger
+	threshold := SlowQueryThreshold
+	SlowQueryThreshold = time.Hour
+	o := NewOrm()
+	SlowQueryThreshold = threshold
 
-	id, err := dORM.Insert(inline)
-	throwFail(t, err)
-	throwFail(t, AssertIs(id, 1))
+	n := o.WithContext(context.Background())
+	throwFailNow(t, n.Begin())
+	defer n.Rollback()
 
-	il := NewInLine()
-	il.ID = 1
-	err = dORM.Read(il)
-	throwFail(t, err)
+	// transaction of derived ormer is not shared with origin
+	_, ok := baseDbQuerier(n.(*orm).db).(*sql.Tx)
+	throwFail(t, AssertIs(ok, true))
+	_, ok = baseDbQuerier(o.(*orm).db).(*sql.Tx)
+	throwFail(t, AssertIs(ok, false))
+}
 
-	throwFail(t, AssertIs(il.Name, name))
-	throwFail(t, AssertIs(il.Email, email))
-	throwFail(t, AssertIs(il.Created.In(DefaultTimeLoc), inline.Created.In(DefaultTimeLoc), testDate))
-	throwFail(t, AssertIs(il.Updated.In(DefaultTimeLoc), inline.Updated.In(DefaultTimeLoc), testDateTime))
+type queryMetrics struct {
+	queries []string
 }
 
-func TestInLineOneToOne(t *testing.T) {
-	name := "121"
-	email := "121@go.com"
-	inline := NewInLine()
-	inline.Name = name
-	inline.Email = email
+func (m *queryMetrics) ObserveQuery(alias, operation, table string, elapsed time.Duration, err error) {
+	m.queries = append(m.queries, fmt.Sprintf("%s %s %s", alias, operation, table))
+}
 
-	id, err := dORM.Insert(inline)
+func TestQueryMetrics(t *testing.T) {
+	m := new(queryMetrics)
+	Metrics = m
+	defer func() { Metrics = nil }()
+
+	ctx, stats := WithQueryStats(context.Background())
+	o := NewOrm().WithContext(ctx)
+	user := User{UserName: "slene"}
+	err := o.Read(&user, "UserName")
 	throwFail(t, err)
-	throwFail(t, AssertIs(id, 2))
+	num, err := o.QueryTable("user").Filter("user_name", "slene").Update(Params{"is_staff": user.IsStaff})
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
 
-	note := "one2one"
-	il121 := NewInLineOneToOne()
-	il121.Note = note
-	il121.InLine = inline
-	_, err = dORM.Insert(il121)
+	throwFail(t, AssertIs(len(m.queries), 2))
+	throwFail(t, AssertIs(m.queries[0], "default SELECT user"))
+	throwFail(t, AssertIs(m.queries[1], "default UPDATE user"))
+	throwFail(t, AssertIs(stats.Count(), 2))
+	throwFail(t, AssertIs(stats.Duration() > 0, true))
+	throwFail(t, AssertIs(QueryStatsFromContext(ctx) == stats, true))
+	throwFail(t, AssertIs(QueryStatsFromContext(context.Background()) == nil, true))
+
+	// queries of orm without stats are not counted
+	err = NewOrm().Read(&user, "UserName")
 	throwFail(t, err)
-	throwFail(t, AssertIs(il121.ID, 1))
+	throwFail(t, AssertIs(len(m.queries), 3))
+	throwFail(t, AssertIs(stats.Count(), 2))
+}
 
-	il := NewInLineOneToOne()
-	err = dORM.QueryTable(il).Filter("Id", 1).RelatedSel().One(il)
+func TestQueryInterceptor(t *testing.T) {
+	errRejected := errors.New("rejected")
+	var queries []string
+	err := AddQueryInterceptor("default",
+		func(ctx context.Context, q *QueryInfo, next QueryInvoker) error {
+			if q.Operation == "DELETE" && q.Table == "user" {
+				return errRejected
+			}
+			q.Query += " /* intercepted */"
+			return next(ctx, q)
+		},
+		func(ctx context.Context, q *QueryInfo, next QueryInvoker) error {
+			err := next(ctx, q)
+			queries = append(queries, fmt.Sprintf("%s %s %s %v", q.Alias, q.Operation, q.Table, strings.HasSuffix(q.Query, "/* intercepted */")))
+			// query rejected by the later interceptor is not executed
+			throwFail(t, AssertIs(q.Duration > 0, err != errRejected))
+			return err
+		},
+	)
+	throwFail(t, err)
+	defer ResetQueryInterceptors("default")
+	throwFail(t, AssertIs(AddQueryInterceptor("unknown"), "DataBase alias name `unknown` not registered"))
 
+	o := NewOrm()
+	user := User{UserName: "slene"}
+	err = o.Read(&user, "UserName")
 	throwFail(t, err)
-	throwFail(t, AssertIs(il.Note, note))
-	throwFail(t, AssertIs(il.InLine.ID, id))
-	throwFail(t, AssertIs(il.InLine.Name, name))
-	throwFail(t, AssertIs(il.InLine.Email, email))
+	throwFail(t, AssertIs(user.UserName, "slene"))
 
-	rinline := NewInLine()
-	err = dORM.QueryTable(rinline).Filter("InLineOneToOne__Id", 1).One(rinline)
+	num, err := o.QueryTable("user").Filter("user_name", "slene").Delete()
+	throwFail(t, AssertIs(err, errRejected))
+	throwFail(t, AssertIs(num, 0))
+
+	// pk of deleted rows are selected before delete
+	throwFail(t, AssertIs(len(queries), 2))
+	throwFail(t, AssertIs(queries[0], "default SELECT user true"))
+	throwFail(t, AssertIs(queries[1], "default SELECT user true"))
 
+	// rejected query row is failed when scanned
+	err = o.Read(&Tag{ID: 1})
 	throwFail(t, err)
-	throwFail(t, AssertIs(rinline.ID, id))
-	throwFail(t, AssertIs(rinline.Name, name))
-	throwFail(t, AssertIs(rinline.Email, email))
+	err = AddQueryInterceptor("default", func(ctx context.Context, q *QueryInfo, next QueryInvoker) error {
+		return errRejected
+	})
+	throwFail(t, err)
+	err = NewOrm().Read(&Tag{ID: 1})
+	throwFail(t, AssertIs(err, errRejected))
+
+	// interceptors are not applied to ormer created before
+	err = o.Read(&Tag{ID: 1})
+	throwFail(t, err)
+	Q := dDbBaser.TableQuote()
+	p, err := o.Raw(fmt.Sprintf("SELECT %sname%s FROM %stag%s WHERE %sid%s = ?", Q, Q, Q, Q, Q, Q)).Prepare()
+	throwFailNow(t, err)
+	_, err = p.Exec(1)
+	throwFail(t, err)
+	throwFail(t, p.Close())
 }
 
-func TestIntegerPk(t *testing.T) {
-	its := []IntegerPk{
-		{ID: math.MinInt64, Value: "-"},
-		{ID: 0, Value: "0"},
-		{ID: math.MaxInt64, Value: "+"},
-	}
+func TestQueryLogCaller(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	debugLog, debug := DebugLog, Debug
+	DebugLog, Debug = zap.New(core), true
+	defer func() { DebugLog, Debug = debugLog, debug }()
 
-	num, err := dORM.InsertMulti(len(its), its)
+	err := AddQueryInterceptor("default", func(ctx context.Context, q *QueryInfo, next QueryInvoker) error {
+		return next(ctx, q)
+	})
 	throwFail(t, err)
-	throwFail(t, AssertIs(num, len(its)))
+	defer ResetQueryInterceptors("default")
 
-	for _, intPk := range its {
-		out := IntegerPk{ID: intPk.ID}
-		err = dORM.Read(&out)
-		throwFail(t, err)
-		throwFail(t, AssertIs(out.Value, intPk.Value))
-	}
+	// caller is out of orm wrappers of querier
+	o := NewOrm().WithContext(context.Background())
+	_, _, line, _ := runtime.Caller(0)
+	throwFail(t, o.Read(&User{ID: 2}))
+	entries := logs.All()
+	throwFailNow(t, AssertIs(len(entries) > 0, true))
+	caller := entries[len(entries)-1].ContextMap()["caller"]
+	throwFail(t, AssertIs(caller, fmt.Sprintf("orm/orm_test.go:%d", line+1)))
+}
 
-	num, err = dORM.InsertMulti(1, []*IntegerPk{{
-		ID: 1, Value: "ok",
-	}})
+func TestHooks(t *testing.T) {
+	h := &Hook{}
+	_, err := dORM.Insert(h)
+	throwFail(t, AssertIs(err.Error(), "name is required"))
+	throwFail(t, AssertIs(h.ID, 0))
+
+	h.Name = "Hello"
+	id, err := dORM.Insert(h)
 	throwFail(t, err)
-	throwFail(t, AssertIs(num, 1))
-}
+	throwFail(t, AssertIs(id > 0, true))
+	throwFail(t, AssertIs(h.Slug, "hello"))
 
-func TestInsertAuto(t *testing.T) {
-	u := &User{
-		UserName: "autoPre",
-		Email:    "autoPre@gmail.com",
-	}
+	num, err := dORM.Update(h)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
 
-	id, err := dORM.Insert(u)
+	r := &Hook{ID: h.ID}
+	err = dORM.Read(r)
 	throwFail(t, err)
+	throwFail(t, AssertIs(r.Slug, "hello"))
+	throwFail(t, AssertIs(r.Updated, 1))
+	throwFail(t, AssertIs(strings.Join(r.events, ","), "AfterRead"))
 
-	id += 100
-	su := &User{
-		ID:       int(id),
-		UserName: "auto",
-		Email:    "auto@gmail.com",
-	}
+	num, err = dORM.Delete(h)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(strings.Join(h.events, ","),
+		"BeforeInsert,AfterInsert,BeforeUpdate,AfterUpdate,BeforeDelete,AfterDelete"))
 
-	nid, err := dORM.Insert(su)
+	hooks := []*Hook{{Name: "A"}, {Name: "B"}}
+	num, err = dORM.InsertMulti(2, hooks)
 	throwFail(t, err)
-	throwFail(t, AssertIs(nid, id))
+	throwFail(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(hooks[1].Slug, "b"))
+}
 
-	users := []User{
-		{ID: int(id + 100), UserName: "auto_100"},
-		{ID: int(id + 110), UserName: "auto_110"},
-		{ID: int(id + 120), UserName: "auto_120"},
-	}
-	num, err := dORM.InsertMulti(100, users)
+func TestSoftDelete(t *testing.T) {
+	t1, t2, t3 := &Trash{Name: "t1"}, &Trash{Name: "t2"}, &Trash{Name: "t3"}
+	num, err := dORM.InsertMulti(1, []*Trash{t1, t2, t3})
 	throwFail(t, err)
 	throwFail(t, AssertIs(num, 3))
 
-	u = &User{
-		UserName: "auto_121",
-	}
+	num, err = dORM.Delete(t1)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(t1.DeletedAt.IsZero(), false))
+	throwFail(t, AssertIs(t1.ID > 0, true))
 
-	nid, err = dORM.Insert(u)
+	err = dORM.Read(&Trash{ID: t1.ID})
+	throwFail(t, AssertIs(err, ErrNoRows))
+
+	num, err = dORM.Delete(t1)
 	throwFail(t, err)
-	throwFail(t, AssertIs(nid, id+120+1))
-}
+	throwFail(t, AssertIs(num, 0))
 
-func TestUintPk(t *testing.T) {
-	name := "go"
-	u := &UintPk{
-		ID:   8,
-		Name: name,
-	}
+	qs := dORM.QueryTable("trash")
+	num, err = qs.Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
 
-	created, _, err := dORM.ReadOrCreate(u, "ID")
+	num, err = qs.Filter("name", "t2").Delete()
 	throwFail(t, err)
-	throwFail(t, AssertIs(created, true))
-	throwFail(t, AssertIs(u.Name, name))
+	throwFail(t, AssertIs(num, 1))
 
-	nu := &UintPk{ID: 8}
-	created, pk, err := dORM.ReadOrCreate(nu, "ID")
+	var trashes []*Trash
+	num, err = qs.All(&trashes)
 	throwFail(t, err)
-	throwFail(t, AssertIs(created, false))
-	throwFail(t, AssertIs(nu.ID, u.ID))
-	throwFail(t, AssertIs(pk, u.ID))
-	throwFail(t, AssertIs(nu.Name, name))
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(trashes[0].Name, "t3"))
 
-	dORM.Delete(u)
-}
+	num, err = qs.WithTrashed().Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 3))
 
-func TestPtrPk(t *testing.T) {
-	parent := &IntegerPk{ID: 10, Value: "10"}
+	num, err = qs.OnlyTrashed().OrderBy("id").All(&trashes)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(trashes[0].Name, "t1"))
+	throwFail(t, AssertIs(trashes[0].DeletedAt.IsZero(), false))
 
-	id, _ := dORM.Insert(parent)
-	if !IsMysql {
-		// MySql does not support last_insert_id in this case: see #2382
-		throwFail(t, AssertIs(id, 10))
-	}
+	num, err = dORM.ForceDelete(t1)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(t1.ID, 0))
 
-	ptr := PtrPk{ID: parent, Positive: true}
-	num, err := dORM.InsertMulti(2, []PtrPk{ptr})
+	// soft deleted row is purged
+	num, err = qs.Filter("name", "t2").ForceDelete()
 	throwFail(t, err)
 	throwFail(t, AssertIs(num, 1))
-	throwFail(t, AssertIs(ptr.ID, parent))
 
-	nptr := &PtrPk{ID: parent}
-	created, pk, err := dORM.ReadOrCreate(nptr, "ID")
+	num, err = qs.Unscoped().Filter("name__in", "t2", "t3").Delete()
 	throwFail(t, err)
-	throwFail(t, AssertIs(created, false))
-	throwFail(t, AssertIs(pk, 10))
-	throwFail(t, AssertIs(nptr.ID, parent))
-	throwFail(t, AssertIs(nptr.Positive, true))
+	throwFail(t, AssertIs(num, 1))
 
-	nptr = &PtrPk{Positive: true}
-	created, pk, err = dORM.ReadOrCreate(nptr, "Positive")
+	num, err = qs.WithTrashed().Count()
 	throwFail(t, err)
-	throwFail(t, AssertIs(created, false))
-	throwFail(t, AssertIs(pk, 10))
-	throwFail(t, AssertIs(nptr.ID, parent))
+	throwFail(t, AssertIs(num, 0))
+}
 
-	nptr.Positive = false
-	num, err = dORM.Update(nptr)
+func TestOptimisticLock(t *testing.T) {
+	v := &Versioned{Name: "v1"}
+	_, err := dORM.Insert(v)
+	throwFail(t, err)
+
+	stale := &Versioned{ID: v.ID}
+	throwFail(t, dORM.Read(stale))
+
+	v.Name = "v2"
+	num, err := dORM.Update(v, "Name")
 	throwFail(t, err)
 	throwFail(t, AssertIs(num, 1))
-	throwFail(t, AssertIs(nptr.ID, parent))
-	throwFail(t, AssertIs(nptr.Positive, false))
+	throwFail(t, AssertIs(v.Version, 1))
 
-	num, err = dORM.Delete(nptr)
+	stale.Name = "v3"
+	num, err = dORM.Update(stale)
+	throwFail(t, AssertIs(err, ErrStaleObject))
+	throwFail(t, AssertIs(num, 0))
+	throwFail(t, AssertIs(stale.Version, 0))
+
+	num, err = dORM.QueryTable("versioned").Filter("id", v.ID).Update(Params{"name": "v4"})
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	throwFail(t, dORM.Read(stale))
+	throwFail(t, AssertIs(stale.Name, "v4"))
+	throwFail(t, AssertIs(stale.Version, 2))
+
+	// only version is increased, other fields are not written
+	stale.Name = "v5"
+	num, err = dORM.Update(stale, "Version")
 	throwFail(t, err)
 	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(stale.Version, 3))
+
+	throwFail(t, dORM.Read(v))
+	throwFail(t, AssertIs(v.Name, "v4"))
+	throwFail(t, AssertIs(v.Version, 3))
 }
 
-func TestSnake(t *testing.T) {
-	cases := map[string]string{
-		"i":           "i",
-		"I":           "i",
-		"iD":          "i_d",
+func TestDirtyTracking(t *testing.T) {
+	tr := &Tracked{Name: "t1", Status: 1}
+	_, err := dORM.Insert(tr)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(len(dORM.Changes(tr)), 0))
+
+	other := &Tracked{ID: tr.ID}
+	throwFailNow(t, dORM.Read(other))
+
+	tr.Name = "t2"
+	changes := dORM.Changes(tr)
+	throwFailNow(t, AssertIs(len(changes), 1))
+	throwFail(t, AssertIs(changes[0].Name, "Name"))
+	throwFail(t, AssertIs(changes[0].Old, "t1"))
+	throwFail(t, AssertIs(changes[0].New, "t2"))
+
+	// concurrent changes of other fields are kept
+	other.Status = 2
+	num, err := dORM.Update(other)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+	num, err = dORM.Update(tr)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(len(dORM.Changes(tr)), 0))
+
+	var rows []*Tracked
+	num, err = dORM.QueryTable("tracked").Filter("id", tr.ID).All(&rows)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(rows[0].Name, "t2"))
+	throwFail(t, AssertIs(rows[0].Status, 2))
+	throwFail(t, AssertIs(len(dORM.Changes(rows[0])), 0))
+
+	// nothing is written without changes
+	num, err = dORM.Update(rows[0])
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 0))
+
+	// untracked model is not reported
+	throwFail(t, AssertIs(dORM.Changes(&Tracked{ID: tr.ID}) == nil, true))
+}
+
+func TestSequence(t *testing.T) {
+	seq := &Sequence{Name: "sales_order", Format: "SO/{yyyy}/{roman}/{n:3}", Reset: ResetMonthly}
+	at := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	code, err := seq.NextAt(dORM, at)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(code, "SO/2019/VII/001"))
+	code, err = seq.NextAt(dORM, at)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(code, "SO/2019/VII/002"))
+	code, err = seq.NextAt(dORM, at.AddDate(0, 1, 0))
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(code, "SO/2019/VIII/001"))
+
+	invoice := &Sequence{Name: "invoice", Format: "INV{yy}{mm}{n:5}", Reset: ResetYearly, Gapless: true}
+	_, err = invoice.NextAt(dORM, at)
+	throwFail(t, AssertIs(err != nil, true))
+
+	// number of rolled back transaction is reused
+	err = dORM.Transaction(func(o Ormer) error {
+		code, err := invoice.NextAt(o, at)
+		throwFail(t, AssertIs(code, "INV190700001"))
+		if err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	throwFail(t, AssertIs(err.Error(), "rollback"))
+	err = dORM.Transaction(func(o Ormer) error {
+		code, err = invoice.NextAt(o, at.AddDate(0, 2, 0))
+		return err
+	})
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(code, "INV190900001"))
+
+	_, err = (&Sequence{Name: "unknown", Format: "{x}"}).NextAt(dORM, at)
+	throwFail(t, AssertIs(err != nil, true))
+}
+
+// in memory cache.Cache for testing query cache, expiry is ignored.
+type memCache struct {
+	items map[string][]byte
+}
+
+func (c *memCache) Get(key string, ptrValue interface{}) error {
+	b, ok := c.items[key]
+	if !ok {
+		return cache.ErrCacheMiss
+	}
+	return cache.Deserialize(b, ptrValue)
+}
+
+func (c *memCache) Set(key string, value interface{}, expires time.Duration) error {
+	b, err := cache.Serialize(value)
+	if err == nil {
+		c.items[key] = b
+	}
+	return err
+}
+
+func (c *memCache) GetMulti(keys ...string) (cache.Getter, error) { return c, nil }
+
+func (c *memCache) Delete(key string) error {
+	delete(c.items, key)
+	return nil
+}
+
+func (c *memCache) Add(key string, value interface{}, expires time.Duration) error {
+	if _, ok := c.items[key]; ok {
+		return cache.ErrNotStored
+	}
+	return c.Set(key, value, expires)
+}
+
+func (c *memCache) Replace(key string, value interface{}, expires time.Duration) error {
+	if _, ok := c.items[key]; !ok {
+		return cache.ErrNotStored
+	}
+	return c.Set(key, value, expires)
+}
+
+func (c *memCache) Flush() error {
+	c.items = make(map[string][]byte)
+	return nil
+}
+
+func TestQueryCache(t *testing.T) {
+	QueryCache = &memCache{items: make(map[string][]byte)}
+	defer func() { QueryCache = nil }()
+
+	Q := dDbBaser.TableQuote()
+	qs := dORM.QueryTable("user").Filter("user_name", "astaxie")
+
+	var user User
+	throwFailNow(t, qs.Cache(time.Minute).One(&user))
+	email := user.Email
+	num, err := qs.Cache(time.Minute).Count()
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	// write not through orm is not seen until invalidated
+	_, err = dORM.Raw(fmt.Sprintf("UPDATE %suser%s SET email = ? WHERE user_name = ?", Q, Q), "raw@example.com", "astaxie").Exec()
+	throwFailNow(t, err)
+	throwFailNow(t, qs.Cache(time.Minute).One(&user))
+	throwFail(t, AssertIs(user.Email, email))
+	throwFailNow(t, qs.One(&user))
+	throwFail(t, AssertIs(user.Email, "raw@example.com"))
+
+	var emails []string
+	rs := dORM.Raw(fmt.Sprintf("SELECT email FROM %suser%s WHERE user_name = ?", Q, Q), "astaxie").Cache(time.Minute, "user")
+	num, err = rs.QueryRows(&emails)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(emails[0], "raw@example.com"))
+
+	// write through orm invalidate cached results of table
+	num, err = qs.Update(Params{"email": email})
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFailNow(t, qs.Cache(time.Minute).One(&user))
+	throwFail(t, AssertIs(user.Email, email))
+	emails = nil
+	_, err = rs.QueryRows(&emails)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(emails[0], email))
+}
+
+func TestReplica(t *testing.T) {
+	b := new(RoundRobinBalancer)
+	throwFail(t, AssertIs(b.Next(3), 0))
+	throwFail(t, AssertIs(b.Next(3), 1))
+	throwFail(t, AssertIs(b.Next(3), 2))
+	throwFail(t, AssertIs(b.Next(3), 0))
+
+	err := RegisterReplica("unknown", "replica", DBARGS.Driver, DBARGS.Source)
+	throwFail(t, AssertIs(err != nil, true))
+
+	al := getDbAlias("default")
+	throwFail(t, AssertIs(al.replica() == nil, true))
+
+	o := NewOrm().(*orm)
+	throwFail(t, AssertIs(o.readDB() == o.db, true))
+
+	replica := &alias{Name: "replica", DB: al.DB}
+	al.Replicas = []*alias{replica, replica}
+	al.Balancer = new(RoundRobinBalancer)
+	defer func() {
+		al.Replicas = nil
+		al.Balancer = nil
+	}()
+	throwFail(t, AssertIs(al.replica(), replica))
+
+	p := o.ForcePrimary().(*orm)
+	throwFail(t, AssertIs(p.readDB() == p.db, true))
+
+	qs := o.QueryTable("user").ForUpdate().(*querySet)
+	throwFail(t, AssertIs(qs.readDB() == o.db, true))
+
+	num, err := o.QueryTable("user").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num > 0, true))
+}
+
+func TestTransactionHelper(t *testing.T) {
+	o := NewOrm()
+	errInner := errors.New("inner")
+
+	err := o.Transaction(func(o Ormer) error {
+		if _, err := o.Insert(&Tag{Name: "tx-outer"}); err != nil {
+			return err
+		}
+		err := o.Transaction(func(o Ormer) error {
+			if _, err := o.Insert(&Tag{Name: "tx-inner"}); err != nil {
+				return err
+			}
+			return errInner
+		})
+		if dDbBaser.SupportSavepoint() {
+			throwFail(t, AssertIs(err, errInner))
+		} else {
+			throwFail(t, AssertIs(err, ErrTxHasBegan))
+		}
+		return nil
+	})
+	throwFail(t, err)
+
+	qs := o.QueryTable("tag")
+	throwFail(t, AssertIs(qs.Filter("name", "tx-outer").Exist(), true))
+	throwFail(t, AssertIs(qs.Filter("name", "tx-inner").Exist(), false))
+
+	err = o.Transaction(func(o Ormer) error {
+		o.Insert(&Tag{Name: "tx-rollback"})
+		return errInner
+	})
+	throwFail(t, AssertIs(err, errInner))
+	throwFail(t, AssertIs(qs.Filter("name", "tx-rollback").Exist(), false))
+
+	func() {
+		defer func() {
+			throwFail(t, AssertIs(recover(), "panic"))
+		}()
+		o.Transaction(func(o Ormer) error {
+			o.Insert(&Tag{Name: "tx-panic"})
+			panic("panic")
+		})
+	}()
+	throwFail(t, AssertIs(qs.Filter("name", "tx-panic").Exist(), false))
+
+	num, err := qs.Filter("name", "tx-outer").Delete()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+}
+
+func TestCursorPagination(t *testing.T) {
+	_, err := DecodeCursor("invalid")
+	throwFail(t, AssertIs(err, ErrInvalidCursor))
+
+	c, err := DecodeCursor((&Cursor{Values: []interface{}{"a", 1}, Before: true}).Encode())
+	throwFail(t, err)
+	throwFail(t, AssertIs(c.Before, true))
+	throwFail(t, AssertIs(len(c.Values), 2))
+
+	for _, name := range []string{"cursor-a", "cursor-b", "cursor-c", "cursor-d", "cursor-e"} {
+		_, err := dORM.Insert(&Tag{Name: name})
+		throwFail(t, err)
+	}
+
+	qs := dORM.QueryTable("tag").Filter("name__startswith", "cursor-").OrderBy("-name").Limit(2)
+
+	var tags []*Tag
+	num, err := qs.All(&tags)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(tags[1].Name, "cursor-d"))
+
+	next, err := qs.NextCursor(tags[1])
+	throwFail(t, err)
+	num, err = qs.After(next).All(&tags)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(tags[0].Name, "cursor-c"))
+	throwFail(t, AssertIs(tags[1].Name, "cursor-b"))
+
+	prev, err := qs.PrevCursor(tags[0])
+	throwFail(t, err)
+	num, err = qs.Before(prev).All(&tags)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(tags[0].Name, "cursor-e"))
+	throwFail(t, AssertIs(tags[1].Name, "cursor-d"))
+
+	rq := &RequestQuery{Limit: 2, Cursor: next, OrderBy: []string{"-name"}}
+	rq.Conditions = []map[string]string{{"name__startswith": "cursor-"}}
+	rqs, _ := rq.Query("tag")
+	num, err = rqs.All(&tags)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(tags[0].Name, "cursor-c"))
+	n, p := rq.Cursors(rqs, &tags)
+	throwFail(t, AssertIs(n != "", true))
+	throwFail(t, AssertIs(p != "", true))
+
+	_, err = qs.After("invalid").All(&tags)
+	throwFail(t, AssertIs(err, ErrInvalidCursor))
+
+	// NULL cannot be compared by keyset, nullable order is rejected
+	nqs := dORM.QueryTable("tag").Filter("name__startswith", "cursor-").OrderBy("best_post").Limit(2)
+	_, err = nqs.NextCursor(tags[0])
+	throwFail(t, AssertIs(err != nil, true))
+	_, err = nqs.After((&Cursor{Values: []interface{}{1, tags[0].ID}}).Encode()).All(&tags)
+	throwFail(t, AssertIs(err != nil, true))
+	_, err = qs.After((&Cursor{Values: []interface{}{nil, tags[0].ID}}).Encode()).All(&tags)
+	throwFail(t, AssertIs(err, ErrInvalidCursor))
+
+	num, err = dORM.QueryTable("tag").Filter("name__startswith", "cursor-").Delete()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 5))
+}
+
+func TestAggregate(t *testing.T) {
+	for _, name := range []string{"agg-a", "agg-a", "agg-b"} {
+		_, err := dORM.Insert(&Tag{Name: name})
+		throwFail(t, err)
+	}
+
+	qs := dORM.QueryTable("tag").Filter("name__startswith", "agg-")
+
+	var maps []Params
+	num, err := qs.Annotate(Count("*").As("total"), Max("id")).GroupBy("name").OrderBy("name").Aggregate(&maps)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(maps[0]["name"], "agg-a"))
+	throwFail(t, AssertIs(maps[0]["total"], "2"))
+	throwFail(t, AssertIs(maps[1]["total"], "1"))
+	throwFail(t, AssertIs(maps[1]["id__max"] != nil, true))
+
+	var rows []struct {
+		Name  string
+		Total int
+	}
+	num, err = qs.Annotate(Count("id").As("total")).GroupBy("name").
+		Having(NewCondition().And("total__gt", 1)).Aggregate(&rows)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(rows[0].Name, "agg-a"))
+	throwFail(t, AssertIs(rows[0].Total, 2))
+
+	var list []ParamsList
+	num, err = qs.Annotate(Count("name").Distinct()).Aggregate(&list)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(list[0][0], "2"))
+
+	num, err = qs.Delete()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 3))
+}
+
+func TestQueryBuilder(t *testing.T) {
+	qb, err := NewQueryBuilder("postgres")
+	throwFailNow(t, err)
+	sub, _ := NewQueryBuilder("postgres")
+	sub.Select("id").From("user_profile").Where("age = ?", 30)
+	qb.Select("id").From(qb.Subquery(sub.String(), "p")).Bind(sub.Args()...).Where("id > ?").Bind(0).And("id").InArgs(1, 2)
+	throwFail(t, AssertIs(qb.String(), "SELECT id FROM (SELECT id FROM user_profile WHERE age = $1) AS p WHERE id > $2 AND id IN ( $3, $4 )"))
+	throwFail(t, AssertIs(len(qb.Args()), 4))
+
+	// marks in literals are kept, chained calls keep the builder of driver
+	sub, _ = NewQueryBuilder("postgres")
+	sub.Select("id").From("tag").Where("name <> '$1?'").And("id > ?", 1)
+	qb, _ = NewQueryBuilder("postgres")
+	query := qb.Select("id").From(qb.Subquery(sub.String(), "t")).Bind(sub.Args()...).Where("id < ?", 9).String()
+	throwFail(t, AssertIs(query, "SELECT id FROM (SELECT id FROM tag WHERE name <> '$1?' AND id > $1) AS t WHERE id < $2"))
+	qb, _ = NewQueryBuilder("sqlite3")
+	throwFail(t, AssertIs(qb.Select("id").From("tag").ForUpdate().String(), "SELECT id FROM tag"))
+
+	qb, err = NewQueryBuilder(DBARGS.Driver)
+	throwFailNow(t, err)
+
+	Q := dDbBaser.TableQuote()
+	qb.Select("user_name").From(Q+"user"+Q).Where("user_name = ?", "slene").Or("user_name").InArgs("astaxie", "nobody").OrderBy("user_name")
+	throwFail(t, AssertIs(len(qb.Args()), 3))
+
+	var names ParamsList
+	num, err := dORM.Raw(qb.String(), qb.Args()...).ValuesFlat(&names)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 3))
+	throwFail(t, AssertIs(names[0], "astaxie"))
+}
+
+func TestLookupOperators(t *testing.T) {
+	qs := dORM.QueryTable("user")
+	total, err := qs.Count()
+	throwFail(t, err)
+
+	year := time.Now().Year()
+	num, err := qs.Filter("created__year", year).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, total))
+
+	num, err = qs.Filter("created__year", year-1).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 0))
+
+	num, err = qs.Filter("created__month", int(time.Now().Month())).Filter("created__week_day", int(time.Now().Weekday())+1).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, total))
+
+	rq := &RequestQuery{Conditions: []map[string]string{{"created.year": fmt.Sprint(year)}}}
+	num, err = qs.SetCond(rq.GetCondition()).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, total))
+
+	if IsSqlite {
+		// sqlite has no regexp function and full-text search on plain table
+		for _, lookup := range []string{"user_name__regex", "user_name__iregex", "user_name__search"} {
+			func() {
+				defer func() {
+					throwFail(t, AssertIs(fmt.Sprint(recover()), fmt.Sprintf("operator `%s` is not supported by driver", strings.TrimPrefix(lookup, "user_name__"))))
+				}()
+				qs.Filter(lookup, "sl").Count()
+			}()
+		}
+	} else {
+		num, err = qs.Filter("user_name__regex", "^sl").Count()
+		throwFail(t, err)
+		throwFail(t, AssertIs(num, 1))
+
+		num, err = qs.Filter("user_name__iregex", "^SL").Count()
+		throwFail(t, err)
+		throwFail(t, AssertIs(num, 1))
+	}
+
+	if IsPostgres {
+		// mysql need FULLTEXT index
+		num, err = qs.Filter("user_name__search", "slene").Count()
+		throwFail(t, err)
+		throwFail(t, AssertIs(num, 1))
+	}
+
+	num, err = qs.Search("sle", "user_name", "email").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+}
+
+func TestExprValues(t *testing.T) {
+	qs := dORM.QueryTable("user")
+	total, err := qs.Count()
+	throwFail(t, err)
+
+	profiles := dORM.QueryTable("user_profile").Filter("age", 30)
+	num, err := qs.Filter("profile__in", profiles.ValuesExpr("id")).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = qs.Filter("id", profiles.ValuesExpr("user")).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = qs.Exclude("profile__in", profiles.ValuesExpr("id")).Filter("profile__isnull", false).Count()
+	throwFail(t, err)
+	with, _ := qs.Filter("profile__isnull", false).Count()
+	throwFail(t, AssertIs(num, with-1))
+
+	num, err = qs.Filter("id", Col("id")).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, total))
+
+	num, err = qs.Filter("id__ne", Col("id")).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 0))
+
+	num, err = qs.Filter("updated__gte", Col("created")).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, total))
+}
+
+func TestRequestQueryFields(t *testing.T) {
+	rq := &RequestQuery{Fields: []string{"user_name", "profile__age"}, Embeds: []string{"profile"}}
+
+	var users []*User
+	num, err := rq.Apply(dORM.QueryTable("user")).Filter("user_name", "astaxie").All(&users)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(users[0].ID, 3))
+	throwFail(t, AssertIs(users[0].UserName, "astaxie"))
+	throwFail(t, AssertIs(users[0].Email, ""))
+	throwFailNow(t, AssertIs(users[0].Profile != nil, true))
+	throwFail(t, AssertIs(users[0].Profile.Age, 30))
+	throwFail(t, AssertIs(users[0].Profile.Money, 0))
+
+	b, err := json.Marshal(rq.Sparse(users))
+	throwFail(t, err)
+	throwFail(t, AssertIs(string(b), `[{"ID":3,"Profile":{"Age":30,"ID":3},"UserName":"astaxie"}]`))
+
+	throwFail(t, rq.Validate("user"))
+	throwFail(t, rq.Validate(new(User)))
+
+	rq.Fields = []string{"unknown"}
+	_, err = rq.Apply(dORM.QueryTable("user")).All(&users)
+	throwFail(t, AssertIs(err != nil, true))
+	err = rq.Validate("user")
+	vr, ok := err.(*validation.Response)
+	throwFailNow(t, AssertIs(ok, true))
+	throwFail(t, AssertIs(vr.GetErrors()["fields.unknown"] != "", true))
+
+	// related field need embeds
+	rq = &RequestQuery{Fields: []string{"profile__age", "profile__unknown"}}
+	_, err = rq.Apply(dORM.QueryTable("user")).All(&users)
+	throwFail(t, AssertIs(err != nil, true))
+	err = rq.Validate("user")
+	vr, ok = err.(*validation.Response)
+	throwFailNow(t, AssertIs(ok, true))
+	throwFail(t, AssertIs(len(vr.GetErrors()), 2))
+	throwFail(t, AssertIs(vr.GetErrors()["fields.profile__age"] != "", true))
+	throwFail(t, AssertIs(vr.GetErrors()["fields.profile__unknown"] != "", true))
+
+	throwFail(t, AssertIs(rq.Validate("unknown") != nil, true))
+}
+
+func TestRequestPolicy(t *testing.T) {
+	policy := &RequestPolicy{
+		Filters:  map[string][]string{"user_name": {"exact", "icontains"}, "status": nil},
+		OrderBy:  []string{"id"},
+		Embeds:   []string{"profile"},
+		MaxLimit: 2,
+	}
+	rq := (&RequestQuery{Policy: policy}).ReadFromContext(url.Values{
+		"conditions": {"user_name.icontains:sle|password:pass|status.in:1.2|a:b:c"},
+		"orderby":    {"-password,id"},
+		"embeds":     {"profile,posts"},
+		"limit":      {"5"},
+	})
+
+	err := rq.Validate()
+	vr, ok := err.(*validation.Response)
+	throwFailNow(t, AssertIs(ok, true))
+	errs := vr.GetErrors()
+	throwFail(t, AssertIs(len(errs), 5))
+	throwFail(t, AssertIs(errs["conditions.password"] != "", true))
+	throwFail(t, AssertIs(errs["conditions.a"] != "", true))
+	throwFail(t, AssertIs(errs["orderby.password"] != "", true))
+	throwFail(t, AssertIs(errs["embeds.posts"] != "", true))
+	throwFail(t, AssertIs(errs["limit"] != "", true))
+
+	// rejected parameters are skipped
+	var users []*User
+	num, err := rq.Apply(dORM.QueryTable("user")).All(&users)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(users[0].UserName, "slene"))
+
+	rq = (&RequestQuery{Policy: policy}).ReadFromContext(url.Values{"conditions": {"status.in:1.2.3"}})
+	throwFail(t, rq.Validate())
+	num, err = rq.Apply(dORM.QueryTable("user")).All(&users)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+
+	// a condition group rejected as a whole adds nothing
+	rq = (&RequestQuery{Policy: policy}).ReadFromContext(url.Values{"conditions": {"password:pass|status.in:1.2.3"}})
+	throwFail(t, AssertIs(rq.Validate() != nil, true))
+	num, err = rq.Apply(dORM.QueryTable("user")).All(&users)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+
+	ctx, stats := WithQueryStats(context.Background())
+	qs, _ := rq.QueryContext(ctx, "user")
+	num, err = qs.All(&users)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(len(users), 2))
+	throwFail(t, AssertIs(stats.Count(), 1))
+}
+
+func TestPrefetch(t *testing.T) {
+	var users []*User
+	qs := dORM.QueryTable("user").Filter("user_name__in", "slene", "astaxie").OrderBy("id")
+	num, err := qs.Prefetch("Posts", NewPrefetch("Posts__Tags").OrderBy("-name")).All(&users)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(num, 2))
+	throwFailNow(t, AssertIs(len(users[0].Posts), 1))
+	throwFail(t, AssertIs(users[0].Posts[0].Title, "Introduction"))
+	throwFailNow(t, AssertIs(len(users[0].Posts[0].Tags), 1))
+	throwFail(t, AssertIs(users[0].Posts[0].Tags[0].Name, "golang"))
+	throwFailNow(t, AssertIs(len(users[1].Posts), 1))
+	throwFail(t, AssertIs(users[1].Posts[0].Title, "Examples"))
+	throwFailNow(t, AssertIs(len(users[1].Posts[0].Tags), 2))
+	throwFail(t, AssertIs(users[1].Posts[0].Tags[0].Name, "golang"))
+	throwFail(t, AssertIs(users[1].Posts[0].Tags[1].Name, "example"))
+
+	posts := NewPrefetch("Posts").SetCond(NewCondition().And("title", "Examples"))
+	num, err = qs.Prefetch(posts).All(&users)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(num, 2))
+	throwFail(t, AssertIs(users[0].Posts != nil, true))
+	throwFail(t, AssertIs(len(users[0].Posts), 0))
+	throwFail(t, AssertIs(len(users[1].Posts), 1))
+
+	// reverse m2m
+	var tag Tag
+	err = dORM.QueryTable("tag").Filter("name", "golang").Prefetch(NewPrefetch("Posts").OrderBy("id")).One(&tag)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(len(tag.Posts), 2))
+	throwFail(t, AssertIs(tag.Posts[0].Title, "Introduction"))
+
+	// embeds of reverse relation are prefetched
+	rq := &RequestQuery{Embeds: []string{"posts"}}
+	num, err = rq.Apply(dORM.QueryTable("user")).Filter("user_name", "astaxie").All(&users)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(len(users[0].Posts), 1))
+}
+
+func TestIterate(t *testing.T) {
+	qs := dORM.QueryTable("user").OrderBy("id")
+	total, err := qs.Count()
+	throwFailNow(t, err)
+
+	var names []string
+	num, err := qs.Iterate(func(md interface{}) error {
+		names = append(names, md.(*User).UserName)
+		return nil
+	})
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, total))
+	throwFailNow(t, AssertIs(len(names), total))
+	throwFail(t, AssertIs(names[0], "slene"))
+
+	// error of fn stop the iteration
+	stop := errors.New("stop")
+	num, err = qs.Iterate(func(md interface{}) error {
+		return stop
+	})
+	throwFail(t, AssertIs(err, stop))
+	throwFail(t, AssertIs(num, 0))
+
+	var sizes []int
+	num, err = qs.IterateBatch(2, func(container interface{}) error {
+		sizes = append(sizes, len(container.([]*User)))
+		return nil
+	})
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, total))
+	throwFailNow(t, AssertIs(len(sizes), (total+1)/2))
+	throwFail(t, AssertIs(sizes[0], 2))
+
+	// relations are prefetched for every chunk
+	var posts int
+	_, err = qs.Filter("user_name__in", "slene", "astaxie").Prefetch("Posts").IterateBatch(1, func(container interface{}) error {
+		posts += len(container.([]*User)[0].Posts)
+		return nil
+	})
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(posts, 2))
+}
+
+func TestReadOrCreate(t *testing.T) {
+	u := &User{
+		UserName: "Kyle",
+		Email:    "kylemcc@gmail.com",
+		Password: "other_pass",
+		Status:   7,
+		IsStaff:  false,
+		IsActive: true,
+	}
+
+	created, pk, err := dORM.ReadOrCreate(u, "UserName")
+	throwFail(t, err)
+	throwFail(t, AssertIs(created, true))
+	throwFail(t, AssertIs(u.ID, pk))
+	throwFail(t, AssertIs(u.UserName, "Kyle"))
+	throwFail(t, AssertIs(u.Email, "kylemcc@gmail.com"))
+	throwFail(t, AssertIs(u.Password, "other_pass"))
+	throwFail(t, AssertIs(u.Status, 7))
+	throwFail(t, AssertIs(u.IsStaff, false))
+	throwFail(t, AssertIs(u.IsActive, true))
+	throwFail(t, AssertIs(u.Created.In(DefaultTimeLoc), u.Created.In(DefaultTimeLoc), testDate))
+	throwFail(t, AssertIs(u.Updated.In(DefaultTimeLoc), u.Updated.In(DefaultTimeLoc), testDateTime))
+
+	nu := &User{UserName: u.UserName, Email: "someotheremail@gmail.com"}
+	created, pk, err = dORM.ReadOrCreate(nu, "UserName")
+	throwFail(t, err)
+	throwFail(t, AssertIs(created, false))
+	throwFail(t, AssertIs(nu.ID, u.ID))
+	throwFail(t, AssertIs(pk, u.ID))
+	throwFail(t, AssertIs(nu.UserName, u.UserName))
+	throwFail(t, AssertIs(nu.Email, u.Email)) // should contain the value in the table, not the one specified above
+	throwFail(t, AssertIs(nu.Password, u.Password))
+	throwFail(t, AssertIs(nu.Status, u.Status))
+	throwFail(t, AssertIs(nu.IsStaff, u.IsStaff))
+	throwFail(t, AssertIs(nu.IsActive, u.IsActive))
+
+	dORM.Delete(u)
+}
+
+func TestInLine(t *testing.T) {
+	name := "inline"
+	email := "hello@go.com"
+	inline := NewInLine()
+	inline.Name = name
+	inline.Email = email
+
+	id, err := dORM.Insert(inline)
+	throwFail(t, err)
+	throwFail(t, AssertIs(id, 1))
+
+	il := NewInLine()
+	il.ID = 1
+	err = dORM.Read(il)
+	throwFail(t, err)
+
+	throwFail(t, AssertIs(il.Name, name))
+	throwFail(t, AssertIs(il.Email, email))
+	throwFail(t, AssertIs(il.Created.In(DefaultTimeLoc), inline.Created.In(DefaultTimeLoc), testDate))
+	throwFail(t, AssertIs(il.Updated.In(DefaultTimeLoc), inline.Updated.In(DefaultTimeLoc), testDateTime))
+}
+
+func TestInLineOneToOne(t *testing.T) {
+	name := "121"
+	email := "121@go.com"
+	inline := NewInLine()
+	inline.Name = name
+	inline.Email = email
+
+	id, err := dORM.Insert(inline)
+	throwFail(t, err)
+	throwFail(t, AssertIs(id, 2))
+
+	note := "one2one"
+	il121 := NewInLineOneToOne()
+	il121.Note = note
+	il121.InLine = inline
+	_, err = dORM.Insert(il121)
+	throwFail(t, err)
+	throwFail(t, AssertIs(il121.ID, 1))
+
+	il := NewInLineOneToOne()
+	err = dORM.QueryTable(il).Filter("Id", 1).RelatedSel().One(il)
+
+	throwFail(t, err)
+	throwFail(t, AssertIs(il.Note, note))
+	throwFail(t, AssertIs(il.InLine.ID, id))
+	throwFail(t, AssertIs(il.InLine.Name, name))
+	throwFail(t, AssertIs(il.InLine.Email, email))
+
+	rinline := NewInLine()
+	err = dORM.QueryTable(rinline).Filter("InLineOneToOne__Id", 1).One(rinline)
+
+	throwFail(t, err)
+	throwFail(t, AssertIs(rinline.ID, id))
+	throwFail(t, AssertIs(rinline.Name, name))
+	throwFail(t, AssertIs(rinline.Email, email))
+}
+
+func TestIntegerPk(t *testing.T) {
+	its := []IntegerPk{
+		{ID: math.MinInt64, Value: "-"},
+		{ID: 0, Value: "0"},
+		{ID: math.MaxInt64, Value: "+"},
+	}
+
+	num, err := dORM.InsertMulti(len(its), its)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, len(its)))
+
+	for _, intPk := range its {
+		out := IntegerPk{ID: intPk.ID}
+		err = dORM.Read(&out)
+		throwFail(t, err)
+		throwFail(t, AssertIs(out.Value, intPk.Value))
+	}
+
+	num, err = dORM.InsertMulti(1, []*IntegerPk{{
+		ID: 1, Value: "ok",
+	}})
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+}
+
+func TestInsertAuto(t *testing.T) {
+	u := &User{
+		UserName: "autoPre",
+		Email:    "autoPre@gmail.com",
+	}
+
+	id, err := dORM.Insert(u)
+	throwFail(t, err)
+
+	id += 100
+	su := &User{
+		ID:       int(id),
+		UserName: "auto",
+		Email:    "auto@gmail.com",
+	}
+
+	nid, err := dORM.Insert(su)
+	throwFail(t, err)
+	throwFail(t, AssertIs(nid, id))
+
+	users := []User{
+		{ID: int(id + 100), UserName: "auto_100"},
+		{ID: int(id + 110), UserName: "auto_110"},
+		{ID: int(id + 120), UserName: "auto_120"},
+	}
+	num, err := dORM.InsertMulti(100, users)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 3))
+
+	u = &User{
+		UserName: "auto_121",
+	}
+
+	nid, err = dORM.Insert(u)
+	throwFail(t, err)
+	throwFail(t, AssertIs(nid, id+120+1))
+}
+
+func TestUintPk(t *testing.T) {
+	name := "go"
+	u := &UintPk{
+		ID:   8,
+		Name: name,
+	}
+
+	created, _, err := dORM.ReadOrCreate(u, "ID")
+	throwFail(t, err)
+	throwFail(t, AssertIs(created, true))
+	throwFail(t, AssertIs(u.Name, name))
+
+	nu := &UintPk{ID: 8}
+	created, pk, err := dORM.ReadOrCreate(nu, "ID")
+	throwFail(t, err)
+	throwFail(t, AssertIs(created, false))
+	throwFail(t, AssertIs(nu.ID, u.ID))
+	throwFail(t, AssertIs(pk, u.ID))
+	throwFail(t, AssertIs(nu.Name, name))
+
+	dORM.Delete(u)
+}
+
+func TestPtrPk(t *testing.T) {
+	parent := &IntegerPk{ID: 10, Value: "10"}
+
+	id, _ := dORM.Insert(parent)
+	if !IsMysql {
+		// MySql does not support last_insert_id in this case: see #2382
+		throwFail(t, AssertIs(id, 10))
+	}
+
+	ptr := PtrPk{ID: parent, Positive: true}
+	num, err := dORM.InsertMulti(2, []PtrPk{ptr})
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(ptr.ID, parent))
+
+	nptr := &PtrPk{ID: parent}
+	created, pk, err := dORM.ReadOrCreate(nptr, "ID")
+	throwFail(t, err)
+	throwFail(t, AssertIs(created, false))
+	throwFail(t, AssertIs(pk, 10))
+	throwFail(t, AssertIs(nptr.ID, parent))
+	throwFail(t, AssertIs(nptr.Positive, true))
+
+	nptr = &PtrPk{Positive: true}
+	created, pk, err = dORM.ReadOrCreate(nptr, "Positive")
+	throwFail(t, err)
+	throwFail(t, AssertIs(created, false))
+	throwFail(t, AssertIs(pk, 10))
+	throwFail(t, AssertIs(nptr.ID, parent))
+
+	nptr.Positive = false
+	num, err = dORM.Update(nptr)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(nptr.ID, parent))
+	throwFail(t, AssertIs(nptr.Positive, false))
+
+	num, err = dORM.Delete(nptr)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+}
+
+func TestSnake(t *testing.T) {
+	cases := map[string]string{
+		"i":           "i",
+		"I":           "i",
+		"iD":          "i_d",
 		"ID":          "i_d",
 		"NO":          "n_o",
 		"NOO":         "n_o_o",
@@ -2378,6 +3384,322 @@ func TestSnake(t *testing.T) {
 	}
 }
 
+func TestJSONValueField(t *testing.T) {
+	doc := &Document{
+		Title: "doc1",
+		Meta:  DocumentMeta{Settings: DocumentSettings{Theme: "dark", Size: 12}, Labels: []string{"a", "b"}},
+		Attrs: map[string]string{"lang": "id"},
+	}
+	_, err := dORM.Insert(doc)
+	throwFailNow(t, err)
+
+	read := &Document{ID: doc.ID}
+	throwFailNow(t, dORM.Read(read))
+	throwFail(t, AssertIs(reflect.DeepEqual(read.Meta, doc.Meta), true))
+	throwFail(t, AssertIs(read.Attrs["lang"], "id"))
+	throwFail(t, AssertIs(read.Tags == nil, true))
+	throwFail(t, AssertIs(read.Layout == nil, true))
+
+	read.Tags = []string{"x"}
+	read.Layout = &DocumentSettings{Theme: "light"}
+	num, err := dORM.Update(read, "Tags", "Layout")
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = dORM.QueryTable("document").Filter("id", doc.ID).Update(Params{"attrs": map[string]string{"lang": "en"}})
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	var docs []*Document
+	num, err = dORM.QueryTable("document").Filter("id", doc.ID).All(&docs)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(reflect.DeepEqual(docs[0].Tags, []string{"x"}), true))
+	throwFail(t, AssertIs(docs[0].Layout.Theme, "light"))
+	throwFail(t, AssertIs(docs[0].Attrs["lang"], "en"))
+	throwFail(t, AssertIs(docs[0].Meta.Settings.Size, 12))
+
+	if !IsMysql && !IsPostgres {
+		return
+	}
+
+	qs := dORM.QueryTable("document")
+	num, err = qs.Filter("meta__settings__theme", "dark").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	num, err = qs.Filter("meta__settings__theme", "light").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 0))
+	num, err = qs.Filter("meta__labels__1", "b").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	num, err = qs.Filter("layout__theme__icontains", "LIG").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+}
+
+func TestUUIDPk(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-([47])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	throwFail(t, AssertIs(uuidPattern.FindStringSubmatch(NewUUIDv4())[1], "4"))
+	throwFail(t, AssertIs(uuidPattern.FindStringSubmatch(NewUUIDv7())[1], "7"))
+
+	acc := &Account{Name: "acc1"}
+	throwFail(t, AssertIs(HasPk(acc), false))
+	_, err := dORM.Insert(acc)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(uuidPattern.FindStringSubmatch(acc.ID)[1], "7"))
+	throwFail(t, AssertIs(HasPk(acc), true))
+
+	time.Sleep(2 * time.Millisecond)
+	acc2 := &Account{Name: "acc2"}
+	_, err = dORM.Insert(acc2)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(acc2.ID > acc.ID, true))
+
+	// given pk is not replaced
+	id := NewUUIDv4()
+	_, err = dORM.Insert(&Account{ID: id, Name: "acc3"})
+	throwFailNow(t, err)
+
+	read := &Account{ID: id}
+	throwFailNow(t, dORM.Read(read))
+	throwFail(t, AssertIs(read.Name, "acc3"))
+
+	read = &Account{ID: acc.ID}
+	throwFailNow(t, dORM.Read(read))
+	throwFail(t, AssertIs(read.Name, "acc1"))
+
+	read.Name = "account1"
+	num, err := dORM.Update(read, "Name")
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = dORM.QueryTable("account").Filter("name", "account1").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+}
+
+func TestCompositePk(t *testing.T) {
+	var acc Account
+	throwFailNow(t, dORM.QueryTable("account").Filter("name", "acc2").One(&acc))
+
+	_, err := dORM.Insert(&AccountMember{Account: &acc, User: &User{ID: 2}, Role: "owner"})
+	throwFailNow(t, err)
+	_, err = dORM.Insert(&AccountMember{Account: &acc, User: &User{ID: 3}, Role: "viewer"})
+	throwFailNow(t, err)
+
+	m := &AccountMember{Account: &acc, User: &User{ID: 3}}
+	throwFailNow(t, dORM.Read(m))
+	throwFail(t, AssertIs(m.Role, "viewer"))
+	throwFail(t, AssertIs(m.Account.ID, acc.ID))
+
+	throwFail(t, AssertIs(dORM.Read(&AccountMember{Account: &acc}), ErrMissPK))
+	throwFail(t, AssertIs(HasPk(&AccountMember{Account: &acc}), false))
+	throwFail(t, AssertIs(HasPk(m), true))
+	throwFail(t, AssertIs(HasPk(&struct{ ID int }{ID: 1}), false))
+
+	m.Role = "editor"
+	num, err := dORM.Update(m, "Role")
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	owner := &AccountMember{Account: &acc, User: &User{ID: 2}}
+	throwFailNow(t, dORM.Read(owner))
+	throwFail(t, AssertIs(owner.Role, "owner"))
+
+	num, err = dORM.Delete(m)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = dORM.QueryTable("account_member").Filter("account", acc.ID).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	sqls, _ := getDbCreateSQL(getDbAlias("default"))
+	Q := dDbBaser.TableQuote()
+	found := false
+	for _, sql := range sqls {
+		if strings.Contains(sql, fmt.Sprintf("%saccount_member%s", Q, Q)) {
+			found = strings.Contains(sql, fmt.Sprintf("PRIMARY KEY (%saccount_id%s, %suser_id%s)", Q, Q, Q, Q))
+		}
+	}
+	throwFail(t, AssertIs(found, true))
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	stmts := splitSQLStatements("CREATE TABLE a (b varchar(10) DEFAULT ';');\nDROP TABLE c;")
+	throwFailNow(t, AssertIs(len(stmts), 2))
+	throwFail(t, AssertIs(stmts[1], "DROP TABLE c"))
+
+	stmts = splitSQLStatements("-- drop a; and b\nDROP TABLE a; /* c; */ DROP TABLE b")
+	throwFailNow(t, AssertIs(len(stmts), 2))
+	throwFail(t, AssertIs(stmts[0], "-- drop a; and b\nDROP TABLE a"))
+
+	body := "CREATE FUNCTION f() RETURNS trigger AS $body$ BEGIN NEW.a := 1; RETURN NEW; END; $body$ LANGUAGE plpgsql"
+	stmts = splitSQLStatements(body + ";\nSELECT $$a;b$$;")
+	throwFailNow(t, AssertIs(len(stmts), 2))
+	throwFail(t, AssertIs(stmts[0], body))
+	throwFail(t, AssertIs(stmts[1], "SELECT $$a;b$$"))
+}
+
+func TestMigration(t *testing.T) {
+	// migrations run on an alias of its own, the model cache and registered migrations are not touched
+	def := getDbAlias("default")
+	al := &alias{Name: "migration", Driver: def.Driver, DriverName: def.DriverName, DB: def.DB, DbBaser: def.DbBaser, TZ: def.TZ}
+
+	table := MigrationTable
+	MigrationTable = "orm_migrations_test"
+	defer func() { MigrationTable = table }()
+
+	Q := al.DbBaser.TableQuote()
+	ms := []*Migration{
+		{
+			Version: "20190101000000",
+			Name:    "create_migration_test",
+			Up:      MigrationSQL(fmt.Sprintf("CREATE TABLE %smigration_test%s (%sid%s integer NOT NULL PRIMARY KEY);", Q, Q, Q, Q)),
+			Down:    MigrationSQL(fmt.Sprintf("DROP TABLE %smigration_test%s;", Q, Q)),
+		},
+		{
+			Version: "20190101000001",
+			Name:    "add_migration_test_name",
+			Up: func(m *Migrator) error {
+				if m.HasColumn("migration_test", "name") {
+					return nil
+				}
+				return m.Exec(fmt.Sprintf("ALTER TABLE %smigration_test%s ADD COLUMN %sname%s %s", Q, Q, Q, Q, fmt.Sprintf(m.ColumnType("string"), 100)))
+			},
+		},
+	}
+
+	n, err := migrateUp(al, ms, 1)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(n, 1))
+
+	n, err = migrateUp(al, ms, 0)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(n, 1))
+
+	status, err := migrateStatus(al, ms)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(len(status), 2))
+	throwFailNow(t, AssertIs(status[1].Applied, true))
+
+	n, err = migrateUp(al, ms, 0)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(n, 0))
+
+	// second migration has no down
+	_, err = migrateDown(al, ms, 1)
+	throwFailNow(t, AssertIs(err != nil, true))
+
+	// first migration is rolled back
+	reverted, err := migrateDown(al, ms[:1], 1)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(len(reverted), 1))
+	throwFailNow(t, AssertIs(reverted[0].Version, "20190101000000"))
+	status, err = migrateStatus(al, ms)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(status[0].Applied, false))
+	throwFail(t, AssertIs(status[1].Applied, true))
+
+	// redo without applied migration applies nothing
+	n, err = migrateRedo(al, ms[:1], 1)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(n, 0))
+
+	// redo applies the rolled back migration only, older pending one is kept
+	redo := &Migration{
+		Version: "20190101000002",
+		Name:    "create_migration_redo",
+		Up:      MigrationSQL(fmt.Sprintf("CREATE TABLE %smigration_redo%s (%sid%s integer NOT NULL PRIMARY KEY);", Q, Q, Q, Q)),
+		Down:    MigrationSQL(fmt.Sprintf("DROP TABLE %smigration_redo%s;", Q, Q)),
+	}
+	n, err = migrateUp(al, []*Migration{redo}, 0)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(n, 1))
+	ms = append(ms, redo)
+	n, err = migrateRedo(al, ms, 1)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(n, 1))
+	status, err = migrateStatus(al, ms)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(status[0].Applied, false))
+	throwFail(t, AssertIs(status[2].Applied, true))
+
+	reverted, err = migrateDown(al, ms, 1)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(len(reverted), 1))
+
+	_, err = al.DB.Exec(fmt.Sprintf("DROP TABLE %s%s%s", Q, MigrationTable, Q))
+	throwFail(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	throwFail(t, AssertIs(normalizeColumnType(DRMySQL, "integer unsigned"), normalizeColumnType(DRMySQL, "int(10) unsigned")))
+	throwFail(t, AssertIs(normalizeColumnType(DRMySQL, "bool"), normalizeColumnType(DRMySQL, "tinyint(1)")))
+	throwFail(t, AssertIs(normalizeColumnType(DRMySQL, "numeric(8, 4)"), "decimal(8,4)"))
+	throwFail(t, AssertIs(normalizeColumnType(DRPostgres, `smallint CHECK("int8" >= -127 AND "int8" <= 128)`), "smallint"))
+	throwFail(t, AssertIs(normalizeColumnDefault("'abc'::character varying"), "abc"))
+	throwFail(t, AssertIs(normalizeColumnDefault("FALSE"), "0"))
+
+	_, err := GetDbDiffSQL("default")
+	throwFail(t, err)
+
+	al := getDbAlias("default")
+	Q := al.DbBaser.TableQuote()
+	mi, _ := modelCache.get("tracked")
+	status := mi.fields.GetByName("Status")
+
+	create, _ := getTableCreateSQL(al, mi)
+	throwFailNow(t, AssertIs(strings.Contains(create, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %stracked%s", Q, Q)), true))
+	hasDiff := func(diffs []string, sql string) bool {
+		for _, diff := range diffs {
+			if diff == sql {
+				return true
+			}
+		}
+		return false
+	}
+
+	// missing table is created
+	_, err = al.DB.Exec(fmt.Sprintf("DROP TABLE %stracked%s", Q, Q))
+	throwFailNow(t, err)
+	diffs, err := getDbDiffSQL(al)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(hasDiff(diffs, create), true))
+
+	// missing column is added
+	var lines []string
+	for _, line := range strings.Split(create, "\n") {
+		if !strings.HasPrefix(line, fmt.Sprintf("    %s%s%s ", Q, status.column, Q)) {
+			lines = append(lines, line)
+		}
+	}
+	_, err = al.DB.Exec(strings.Join(lines, "\n"))
+	throwFailNow(t, err)
+	diffs, err = getDbDiffSQL(al)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(hasDiff(diffs, create), false))
+	throwFail(t, AssertIs(hasDiff(diffs, getColumnAddQuery(al, status)+";"), true))
+
+	// column not in model is dropped
+	_, err = al.DB.Exec(fmt.Sprintf("ALTER TABLE %stracked%s ADD COLUMN %sdiff_extra%s integer", Q, Q, Q, Q))
+	throwFailNow(t, err)
+	diffs, err = getDbDiffSQL(al)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(hasDiff(diffs, fmt.Sprintf("ALTER TABLE %stracked%s DROP COLUMN %sdiff_extra%s;", Q, Q, Q, Q)), true))
+
+	_, err = al.DB.Exec(fmt.Sprintf("DROP TABLE %stracked%s", Q, Q))
+	throwFailNow(t, err)
+	_, err = al.DB.Exec(create)
+	throwFailNow(t, err)
+
+	// unique index of missing column is built from field
+	umi, _ := modelCache.get("user")
+	throwFail(t, AssertIs(getColumnUniqueQuery(al, umi.fields.GetByName("UserName")),
+		fmt.Sprintf("CREATE UNIQUE INDEX %suser_user_name_uniq%s ON %suser%s (%suser_name%s);", Q, Q, Q, Q, Q, Q)))
+}
+
 func TestIgnoreCaseTag(t *testing.T) {
 	type testTagModel struct {
 		ID     int    `orm:"pk"`
@@ -2491,3 +3813,35 @@ func TestInsertOrUpdate(t *testing.T) {
 		throwFailNow(t, AssertIs((((user2.Status+1)-1)*3)/3, test.Status))
 	}
 }
+
+func TestInsertOrUpdateMulti(t *testing.T) {
+	users := []*User{
+		{UserName: "unique_username133", Email: "133@example.com", Status: 5, Password: "o"},
+		{UserName: "unique_username134", Email: "134@example.com", Status: 6, Password: "o"},
+	}
+	num, err := dORM.InsertOrUpdateMulti(2, users, []string{"user_name"}, "status")
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num > 0, true))
+
+	for _, u := range users {
+		test := User{UserName: u.UserName}
+		throwFailNow(t, dORM.Read(&test, "UserName"))
+		throwFail(t, AssertIs(test.Status, u.Status))
+	}
+
+	// only the given cols are updated
+	test := User{UserName: "unique_username133"}
+	throwFailNow(t, dORM.Read(&test, "UserName"))
+	throwFail(t, AssertIs(test.Email == "133@example.com", false))
+
+	users[1].Status = 7
+	_, err = dORM.InsertOrUpdateMulti(1, users[1:], []string{"user_name"})
+	throwFailNow(t, err)
+	test = User{UserName: "unique_username134"}
+	throwFailNow(t, dORM.Read(&test, "UserName"))
+	throwFail(t, AssertIs(test.Status, 7))
+
+	_, err = dORM.InsertOrUpdateMulti(1, users, []string{"user_name"}, "no_such_column")
+	throwFail(t, AssertIs(err != nil, true))
+}
+
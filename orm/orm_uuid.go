@@ -0,0 +1,66 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+)
+
+// NewUUIDv4 generates a random uuid, e.g. 0c2b7f0e-3f7a-4c55-9b1e-5a3d2f1e8c47.
+// it is generated on insert for empty pk tagged by `orm:"pk;type(uuid)"`.
+func NewUUIDv4() string {
+	var u [16]byte
+	readRandom(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+// NewUUIDv7 generates a time-ordered uuid, the leading 48 bits are unix time in milliseconds,
+// so uuids generated later are sorted after, which keeps pk index insertion sequential.
+// it is generated on insert for empty pk tagged by `orm:"pk;type(uuid7)"`.
+func NewUUIDv7() string {
+	var u [16]byte
+	readRandom(u[6:])
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	copy(u[:6], ts[2:])
+	u[6] = u[6]&0x0f | 0x70
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+// generate uuid of version, v4 or v7.
+func newUUID(version string) string {
+	if version == "v7" {
+		return NewUUIDv7()
+	}
+	return NewUUIDv4()
+}
+
+// fill b with random bytes.
+func readRandom(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
+
+// format uuid in canonical 8-4-4-4-12 form.
+func formatUUID(u [16]byte) string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
@@ -14,9 +14,14 @@
 
 package orm
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
-// QueryBuilder is the Query builder interface
+// QueryBuilder is the Query builder interface.
+// conditions may contain `?` marks, the bound args are collected in order
+// and returned by Args, so the result can be used as Ormer.Raw(qb.String(), qb.Args()...)
 type QueryBuilder interface {
 	Select(fields ...string) QueryBuilder
 	ForUpdate() QueryBuilder
@@ -24,23 +29,27 @@ type QueryBuilder interface {
 	InnerJoin(table string) QueryBuilder
 	LeftJoin(table string) QueryBuilder
 	RightJoin(table string) QueryBuilder
-	On(cond string) QueryBuilder
-	Where(cond string) QueryBuilder
-	And(cond string) QueryBuilder
-	Or(cond string) QueryBuilder
+	On(cond string, args ...interface{}) QueryBuilder
+	Where(cond string, args ...interface{}) QueryBuilder
+	And(cond string, args ...interface{}) QueryBuilder
+	Or(cond string, args ...interface{}) QueryBuilder
 	In(vals ...string) QueryBuilder
+	InArgs(vals ...interface{}) QueryBuilder
 	OrderBy(fields ...string) QueryBuilder
 	Asc() QueryBuilder
 	Desc() QueryBuilder
 	Limit(limit int) QueryBuilder
 	Offset(offset int) QueryBuilder
 	GroupBy(fields ...string) QueryBuilder
-	Having(cond string) QueryBuilder
+	Having(cond string, args ...interface{}) QueryBuilder
 	Update(tables ...string) QueryBuilder
 	Set(kv ...string) QueryBuilder
 	Delete(tables ...string) QueryBuilder
 	InsertInto(table string, fields ...string) QueryBuilder
 	Values(vals ...string) QueryBuilder
+	ValuesArgs(vals ...interface{}) QueryBuilder
+	Bind(args ...interface{}) QueryBuilder
+	Args() []interface{}
 	Subquery(sub string, alias string) string
 	String() string
 }
@@ -52,11 +61,19 @@ func NewQueryBuilder(driver string) (qb QueryBuilder, err error) {
 	} else if driver == "tidb" {
 		qb = new(TiDBQueryBuilder)
 	} else if driver == "postgres" {
-		err = errors.New("postgres query builder is not supported yet")
-	} else if driver == "sqlite" {
-		err = errors.New("sqlite query builder is not supported yet")
+		qb = newPostgresQueryBuilder()
+	} else if driver == "sqlite" || driver == "sqlite3" {
+		qb = newSQLiteQueryBuilder()
 	} else {
 		err = errors.New("unknown driver for query builder")
 	}
 	return
 }
+
+// generate n `?` marks joined by comma
+func qbMarks(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
@@ -25,127 +25,149 @@ const CommaSpace = ", "
 
 // MySQLQueryBuilder is the SQL build
 type MySQLQueryBuilder struct {
-	Tokens []string
+	Tokens    []string
+	Arguments []interface{}
+	self      QueryBuilder // builder embedding this one, returned by chained calls
+}
+
+// get the builder returned by chained calls.
+func (qb *MySQLQueryBuilder) chain() QueryBuilder {
+	if qb.self != nil {
+		return qb.self
+	}
+	return qb
 }
 
 // Select will join the fields
 func (qb *MySQLQueryBuilder) Select(fields ...string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "SELECT", strings.Join(fields, CommaSpace))
-	return qb
+	return qb.chain()
 }
 
 // ForUpdate add the FOR UPDATE clause
 func (qb *MySQLQueryBuilder) ForUpdate() QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "FOR UPDATE")
-	return qb
+	return qb.chain()
 }
 
 // From join the tables
 func (qb *MySQLQueryBuilder) From(tables ...string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "FROM", strings.Join(tables, CommaSpace))
-	return qb
+	return qb.chain()
 }
 
 // InnerJoin INNER JOIN the table
 func (qb *MySQLQueryBuilder) InnerJoin(table string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "INNER JOIN", table)
-	return qb
+	return qb.chain()
 }
 
 // LeftJoin LEFT JOIN the table
 func (qb *MySQLQueryBuilder) LeftJoin(table string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "LEFT JOIN", table)
-	return qb
+	return qb.chain()
 }
 
 // RightJoin RIGHT JOIN the table
 func (qb *MySQLQueryBuilder) RightJoin(table string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "RIGHT JOIN", table)
-	return qb
+	return qb.chain()
 }
 
-// On join with on cond
-func (qb *MySQLQueryBuilder) On(cond string) QueryBuilder {
+// On join with on cond, args are bound to the `?` marks of cond
+func (qb *MySQLQueryBuilder) On(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "ON", cond)
-	return qb
+	qb.Arguments = append(qb.Arguments, args...)
+	return qb.chain()
 }
 
-// Where join the Where cond
-func (qb *MySQLQueryBuilder) Where(cond string) QueryBuilder {
+// Where join the Where cond, args are bound to the `?` marks of cond
+func (qb *MySQLQueryBuilder) Where(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "WHERE", cond)
-	return qb
+	qb.Arguments = append(qb.Arguments, args...)
+	return qb.chain()
 }
 
-// And join the and cond
-func (qb *MySQLQueryBuilder) And(cond string) QueryBuilder {
+// And join the and cond, args are bound to the `?` marks of cond
+func (qb *MySQLQueryBuilder) And(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "AND", cond)
-	return qb
+	qb.Arguments = append(qb.Arguments, args...)
+	return qb.chain()
 }
 
-// Or join the or cond
-func (qb *MySQLQueryBuilder) Or(cond string) QueryBuilder {
+// Or join the or cond, args are bound to the `?` marks of cond
+func (qb *MySQLQueryBuilder) Or(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "OR", cond)
-	return qb
+	qb.Arguments = append(qb.Arguments, args...)
+	return qb.chain()
 }
 
 // In join the IN (vals)
 func (qb *MySQLQueryBuilder) In(vals ...string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "IN", "(", strings.Join(vals, CommaSpace), ")")
-	return qb
+	return qb.chain()
+}
+
+// InArgs join the IN (?, ...) with bound vals
+func (qb *MySQLQueryBuilder) InArgs(vals ...interface{}) QueryBuilder {
+	qb.Tokens = append(qb.Tokens, "IN", "(", qbMarks(len(vals)), ")")
+	qb.Arguments = append(qb.Arguments, vals...)
+	return qb.chain()
 }
 
 // OrderBy join the Order by fields
 func (qb *MySQLQueryBuilder) OrderBy(fields ...string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "ORDER BY", strings.Join(fields, CommaSpace))
-	return qb
+	return qb.chain()
 }
 
 // Asc join the asc
 func (qb *MySQLQueryBuilder) Asc() QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "ASC")
-	return qb
+	return qb.chain()
 }
 
 // Desc join the desc
 func (qb *MySQLQueryBuilder) Desc() QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "DESC")
-	return qb
+	return qb.chain()
 }
 
 // Limit join the limit num
 func (qb *MySQLQueryBuilder) Limit(limit int) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "LIMIT", strconv.Itoa(limit))
-	return qb
+	return qb.chain()
 }
 
 // Offset join the offset num
 func (qb *MySQLQueryBuilder) Offset(offset int) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "OFFSET", strconv.Itoa(offset))
-	return qb
+	return qb.chain()
 }
 
 // GroupBy join the Group by fields
 func (qb *MySQLQueryBuilder) GroupBy(fields ...string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "GROUP BY", strings.Join(fields, CommaSpace))
-	return qb
+	return qb.chain()
 }
 
-// Having join the Having cond
-func (qb *MySQLQueryBuilder) Having(cond string) QueryBuilder {
+// Having join the Having cond, args are bound to the `?` marks of cond
+func (qb *MySQLQueryBuilder) Having(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "HAVING", cond)
-	return qb
+	qb.Arguments = append(qb.Arguments, args...)
+	return qb.chain()
 }
 
 // Update join the update table
 func (qb *MySQLQueryBuilder) Update(tables ...string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "UPDATE", strings.Join(tables, CommaSpace))
-	return qb
+	return qb.chain()
 }
 
 // Set join the set kv
 func (qb *MySQLQueryBuilder) Set(kv ...string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "SET", strings.Join(kv, CommaSpace))
-	return qb
+	return qb.chain()
 }
 
 // Delete join the Delete tables
@@ -154,7 +176,7 @@ func (qb *MySQLQueryBuilder) Delete(tables ...string) QueryBuilder {
 	if len(tables) != 0 {
 		qb.Tokens = append(qb.Tokens, strings.Join(tables, CommaSpace))
 	}
-	return qb
+	return qb.chain()
 }
 
 // InsertInto join the insert SQL
@@ -164,14 +186,32 @@ func (qb *MySQLQueryBuilder) InsertInto(table string, fields ...string) QueryBui
 		fieldsStr := strings.Join(fields, CommaSpace)
 		qb.Tokens = append(qb.Tokens, "(", fieldsStr, ")")
 	}
-	return qb
+	return qb.chain()
 }
 
 // Values join the Values(vals)
 func (qb *MySQLQueryBuilder) Values(vals ...string) QueryBuilder {
 	valsStr := strings.Join(vals, CommaSpace)
 	qb.Tokens = append(qb.Tokens, "VALUES", "(", valsStr, ")")
-	return qb
+	return qb.chain()
+}
+
+// ValuesArgs join the VALUES (?, ...) with bound vals
+func (qb *MySQLQueryBuilder) ValuesArgs(vals ...interface{}) QueryBuilder {
+	qb.Tokens = append(qb.Tokens, "VALUES", "(", qbMarks(len(vals)), ")")
+	qb.Arguments = append(qb.Arguments, vals...)
+	return qb.chain()
+}
+
+// Bind add args for the `?` marks of previous fragments, e.g. Set("name = ?").Bind(name)
+func (qb *MySQLQueryBuilder) Bind(args ...interface{}) QueryBuilder {
+	qb.Arguments = append(qb.Arguments, args...)
+	return qb.chain()
+}
+
+// Args return the bound args in order of `?` marks
+func (qb *MySQLQueryBuilder) Args() []interface{} {
+	return qb.Arguments
 }
 
 // Subquery join the sub as alias
@@ -0,0 +1,68 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PostgresQueryBuilder is the SQL build for postgres, use `$n` marks for bound args.
+// fragments keep `?` marks, they are numbered once by String.
+// create it by NewQueryBuilder, so chained calls return the postgres builder.
+type PostgresQueryBuilder struct {
+	MySQLQueryBuilder
+}
+
+// Subquery join the sub as alias,
+// the `$n` marks of sub built by PostgresQueryBuilder are turned back to `?`,
+// bind the args of sub in its position by Bind.
+func (qb *PostgresQueryBuilder) Subquery(sub string, alias string) string {
+	return fmt.Sprintf("(%s) AS %s", replacePgMarks(sub, false), alias)
+}
+
+// String join all Tokens, the `?` marks are replaced by `$n`
+func (qb *PostgresQueryBuilder) String() string {
+	return replacePgMarks(qb.MySQLQueryBuilder.String(), true)
+}
+
+// create postgres query builder.
+func newPostgresQueryBuilder() *PostgresQueryBuilder {
+	qb := new(PostgresQueryBuilder)
+	qb.self = qb
+	return qb
+}
+
+// replace `?` marks by `$n` in order, or `$n` marks back to `?`.
+// marks inside quoted literals and identifiers are kept.
+func replacePgMarks(query string, number bool) string {
+	data := make([]byte, 0, len(query))
+	var quote byte
+	num := 1
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case number && c == '?':
+			data = append(data, '$')
+			data = strconv.AppendInt(data, int64(num), 10)
+			num++
+			continue
+		case !number && c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			for i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9' {
+				i++
+			}
+			data = append(data, '?')
+			continue
+		}
+		data = append(data, c)
+	}
+	return string(data)
+}
@@ -0,0 +1,23 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package orm
+
+// SQLiteQueryBuilder is the SQL build for sqlite.
+// create it by NewQueryBuilder, so chained calls return the sqlite builder.
+type SQLiteQueryBuilder struct {
+	MySQLQueryBuilder
+}
+
+// ForUpdate is ignored, sqlite not support FOR UPDATE
+func (qb *SQLiteQueryBuilder) ForUpdate() QueryBuilder {
+	return qb
+}
+
+// create sqlite query builder.
+func newSQLiteQueryBuilder() *SQLiteQueryBuilder {
+	qb := new(SQLiteQueryBuilder)
+	qb.self = qb
+	return qb
+}
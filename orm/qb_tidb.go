@@ -22,7 +22,8 @@ import (
 
 // TiDBQueryBuilder is the SQL build
 type TiDBQueryBuilder struct {
-	Tokens []string
+	Tokens    []string
+	Arguments []interface{}
 }
 
 // Select will join the fields
@@ -61,27 +62,31 @@ func (qb *TiDBQueryBuilder) RightJoin(table string) QueryBuilder {
 	return qb
 }
 
-// On join with on cond
-func (qb *TiDBQueryBuilder) On(cond string) QueryBuilder {
+// On join with on cond, args are bound to the `?` marks of cond
+func (qb *TiDBQueryBuilder) On(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "ON", cond)
+	qb.Arguments = append(qb.Arguments, args...)
 	return qb
 }
 
-// Where join the Where cond
-func (qb *TiDBQueryBuilder) Where(cond string) QueryBuilder {
+// Where join the Where cond, args are bound to the `?` marks of cond
+func (qb *TiDBQueryBuilder) Where(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "WHERE", cond)
+	qb.Arguments = append(qb.Arguments, args...)
 	return qb
 }
 
-// And join the and cond
-func (qb *TiDBQueryBuilder) And(cond string) QueryBuilder {
+// And join the and cond, args are bound to the `?` marks of cond
+func (qb *TiDBQueryBuilder) And(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "AND", cond)
+	qb.Arguments = append(qb.Arguments, args...)
 	return qb
 }
 
-// Or join the or cond
-func (qb *TiDBQueryBuilder) Or(cond string) QueryBuilder {
+// Or join the or cond, args are bound to the `?` marks of cond
+func (qb *TiDBQueryBuilder) Or(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "OR", cond)
+	qb.Arguments = append(qb.Arguments, args...)
 	return qb
 }
 
@@ -91,6 +96,13 @@ func (qb *TiDBQueryBuilder) In(vals ...string) QueryBuilder {
 	return qb
 }
 
+// InArgs join the IN (?, ...) with bound vals
+func (qb *TiDBQueryBuilder) InArgs(vals ...interface{}) QueryBuilder {
+	qb.Tokens = append(qb.Tokens, "IN", "(", qbMarks(len(vals)), ")")
+	qb.Arguments = append(qb.Arguments, vals...)
+	return qb
+}
+
 // OrderBy join the Order by fields
 func (qb *TiDBQueryBuilder) OrderBy(fields ...string) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "ORDER BY", strings.Join(fields, CommaSpace))
@@ -127,9 +139,10 @@ func (qb *TiDBQueryBuilder) GroupBy(fields ...string) QueryBuilder {
 	return qb
 }
 
-// Having join the Having cond
-func (qb *TiDBQueryBuilder) Having(cond string) QueryBuilder {
+// Having join the Having cond, args are bound to the `?` marks of cond
+func (qb *TiDBQueryBuilder) Having(cond string, args ...interface{}) QueryBuilder {
 	qb.Tokens = append(qb.Tokens, "HAVING", cond)
+	qb.Arguments = append(qb.Arguments, args...)
 	return qb
 }
 
@@ -171,6 +184,24 @@ func (qb *TiDBQueryBuilder) Values(vals ...string) QueryBuilder {
 	return qb
 }
 
+// ValuesArgs join the VALUES (?, ...) with bound vals
+func (qb *TiDBQueryBuilder) ValuesArgs(vals ...interface{}) QueryBuilder {
+	qb.Tokens = append(qb.Tokens, "VALUES", "(", qbMarks(len(vals)), ")")
+	qb.Arguments = append(qb.Arguments, vals...)
+	return qb
+}
+
+// Bind add args for the `?` marks of previous fragments, e.g. Set("name = ?").Bind(name)
+func (qb *TiDBQueryBuilder) Bind(args ...interface{}) QueryBuilder {
+	qb.Arguments = append(qb.Arguments, args...)
+	return qb
+}
+
+// Args return the bound args in order of `?` marks
+func (qb *TiDBQueryBuilder) Args() []interface{} {
+	return qb.Arguments
+}
+
 // Subquery join the sub as alias
 func (qb *TiDBQueryBuilder) Subquery(sub string, alias string) string {
 	return fmt.Sprintf("(%s) AS %s", sub, alias)
@@ -56,6 +56,8 @@ type Ormer interface {
 	//  user := new(User)
 	//  id, err = Ormer.Insert(user)
 	//  user must a pointer and Insert will set user's pk field
+	//  BeforeInsert and AfterInsert hooks of user are called when implemented,
+	//  so do the hooks of Read, Update and Delete.
 	Insert(interface{}) (int64, error)
 	// mysql:InsertOrUpdate(model) or InsertOrUpdate(model,"colu=colu+value")
 	// if colu type is integer : can use(+-*/), string : convert(colu,"value")
@@ -64,6 +66,14 @@ type Ormer interface {
 	InsertOrUpdate(md interface{}, colConflitAndArgs ...string) (int64, error)
 	// insert some models to database
 	InsertMulti(bulk int, mds interface{}) (int64, error)
+	// insert some models to database, rows conflict on conflicts columns update the cols instead.
+	// cols default to all inserted columns except conflicts, pk and auto_now_add columns.
+	// rows are inserted by bulk in one sql as InsertMulti, the pk of models is not set.
+	// mysql and tidb resolve conflict of any unique key, conflicts are not used.
+	// for example:
+	//	num, err = Ormer.InsertOrUpdateMulti(100, users, []string{"user_name"}, "email", "status")
+	// num is the affected rows, mysql count 2 for every updated row.
+	InsertOrUpdateMulti(bulk int, mds interface{}, conflicts []string, cols ...string) (int64, error)
 	// update model to database.
 	// cols set the columns those want to update.
 	// find model by Id(pk) field and update columns specified by fields, if cols is null then update all columns
@@ -73,9 +83,22 @@ type Ormer interface {
 	//	user.Extra.Name = "beego"
 	//	user.Extra.Data = "orm"
 	//	num, err = Ormer.Update(&user, "Langs", "Extra")
+	// model with version field is updated only when version is unchanged in database,
+	// the version is increased and ErrStaleObject is returned when no row matched.
+	// model embed Tracker update the changed fields only when cols is empty.
 	Update(md interface{}, cols ...string) (int64, error)
+	// get changed fields of model embed Tracker since it is loaded by Read, One or All,
+	// or saved by Insert or Update. nil when model is not tracked.
+	// for example:
+	//	for _, c := range Ormer.Changes(&user) {
+	//		log.Printf("%s: %v => %v", c.Name, c.Old, c.New)
+	//	}
+	Changes(md interface{}) []FieldChange
 	// delete model in database
+	// model with soft_delete field is marked as deleted instead of removed.
 	Delete(md interface{}, cols ...string) (int64, error)
+	// delete model in database, soft_delete field is ignored.
+	ForceDelete(md interface{}, cols ...string) (int64, error)
 	// load related models to md model.
 	// args are limit, offset int and order string.
 	//
@@ -118,6 +141,23 @@ type Ormer interface {
 	//  ...
 	//  err = o.Rollback()
 	BeginTx(ctx context.Context, opts *sql.TxOptions) error
+	// run fn in transaction, commit when fn return nil,
+	// rollback when fn return error or panic.
+	// when transaction has began, fn runs inside a savepoint instead,
+	// so functions using Transaction can be composed.
+	// for example:
+	//	err := o.Transaction(func(o Ormer) error {
+	//		if _, err := o.Insert(&user); err != nil {
+	//			return err
+	//		}
+	//		return o.Transaction(func(o Ormer) error {
+	//			_, err := o.Insert(&profile) // rollback profile only on error
+	//			return err
+	//		})
+	//	})
+	Transaction(fn func(o Ormer) error) error
+	// like Transaction, begin transaction with provided context and option.
+	TransactionTx(ctx context.Context, opts *sql.TxOptions, fn func(o Ormer) error) error
 	// commit transaction
 	Commit() error
 	// rollback transaction
@@ -127,6 +167,18 @@ type Ormer interface {
 	//	 ormer.Raw("UPDATE `user` SET `user_name` = ? WHERE `user_name` = ?", "slene", "testing").Exec()
 	//	// update user testing's name to slene
 	Raw(query string, args ...interface{}) RawSeter
+	// return a copy of ormer bound to context, every query executed
+	// by the copy and its QuerySeter, QueryM2Mer and RawSeter use the context.
+	// a transaction began on the copy must be ended on the copy.
+	// for example:
+	//	o := NewOrm().WithContext(ctx)
+	//	err := o.Read(&user) // aborted when ctx is canceled
+	WithContext(ctx context.Context) Ormer
+	// return a copy of ormer that reads from primary database only,
+	// useful to read data right after it was written.
+	// for example:
+	//	o.ForcePrimary().QueryTable("user").Filter("id", id).One(&user)
+	ForcePrimary() Ormer
 	Driver() Driver
 	DBStats() *sql.DBStats
 }
@@ -147,6 +199,8 @@ type QuerySeter interface {
 	//	Filter("profile__Age", 28)
 	// 	 // time compare
 	//	qs.Filter("created", time.Now())
+	//	// value at path of json field, compared as text, mysql and postgres only
+	//	qs.Filter("meta__settings__theme", "dark")
 	Filter(string, ...interface{}) QuerySeter
 	// add raw sql to querySeter.
 	// for example:
@@ -202,16 +256,97 @@ type QuerySeter interface {
 	//	qs.RelatedSel("profile").One(&user)
 	//	user.Profile.Age = 32
 	RelatedSel(params ...interface{}) QuerySeter
+	// read rows one by one into new model, fn receive the pointer of model.
+	// it is for large result, rows are not limited by DefaultRowsLimit unless Limit is set.
+	// return error of fn to stop the iteration.
+	// for example:
+	//	qs.Iterate(func(md interface{}) error {
+	//		user := md.(*User)
+	//		return w.Write(user)
+	//	})
+	Iterate(fn func(md interface{}) error) (int64, error)
+	// read rows in chunks of size, fn receive slice of model pointers, e.g. []*User.
+	// prefetched relations are loaded for every chunk.
+	// for example:
+	//	qs.IterateBatch(500, func(container interface{}) error {
+	//		users := container.([]*User)
+	//		return export(users)
+	//	})
+	IterateBatch(size int, fn func(container interface{}) error) (int64, error)
+	// cache results of All, One, Count and Values in QueryCache for ttl,
+	// keyed by the generated sql and args. cached results are invalidated when
+	// the queried tables are written by orm, queries in transaction are not cached.
+	// for example:
+	//	qs.Filter("status", 1).Cache(time.Minute).All(&users)
+	Cache(ttl time.Duration) QuerySeter
+	// set reverse, m2m or fk relations loaded by one IN query per relation after All and One,
+	// instead of LoadRelated for every model.
+	// params can be relation name, nested relation joined by "__", or *Prefetch with condition and ordering.
+	// for example:
+	//	qs.Prefetch("Posts__Tags").All(&users)
+	//	qs.Prefetch(orm.NewPrefetch("Posts").SetCond(cond).OrderBy("-id")).All(&users)
+	Prefetch(params ...interface{}) QuerySeter
 	// Set Distinct
 	// for example:
 	//  o.QueryTable("policy").Filter("Groups__Group__Users__User", user).
 	//    Distinct().
 	//    All(&permissions)
 	Distinct() QuerySeter
+	// select only the fields when reading models by All and One,
+	// pk is always selected and the other fields keep zero value.
+	// fields of related models selected by RelatedSel can be used by path.
+	// for example:
+	//	qs.RelatedSel("profile").Only("user_name", "profile__age").All(&users)
+	Only(fields ...string) QuerySeter
 	// set FOR UPDATE to query.
 	// for example:
 	//  o.QueryTable("user").Filter("uid", uid).ForUpdate().All(&users)
 	ForUpdate() QuerySeter
+	// include soft deleted rows in query and make Delete remove rows.
+	// for example:
+	//  o.QueryTable("user").Unscoped().Filter("name", "slene").Delete()
+	Unscoped() QuerySeter
+	// include soft deleted rows in query.
+	// for example:
+	//  o.QueryTable("user").WithTrashed().All(&users)
+	WithTrashed() QuerySeter
+	// query soft deleted rows only.
+	// for example:
+	//  o.QueryTable("user").OnlyTrashed().All(&users)
+	OnlyTrashed() QuerySeter
+	// read from primary database even replicas are registered.
+	// for example:
+	//  o.QueryTable("user").ForcePrimary().Filter("id", id).One(&user)
+	ForcePrimary() QuerySeter
+	// add aggregations to select of Aggregate.
+	// for example:
+	//  qs.Annotate(Sum("amount").As("total"), Count("*")).GroupBy("status").Aggregate(&res)
+	//  // res is []Params{{"status": "paid", "total": "100", "all__count": "2"}, ...}
+	Annotate(aggs ...*Aggregation) QuerySeter
+	// set HAVING condition of Aggregate, expression can use annotated names.
+	// for example:
+	//  qs.Annotate(Sum("amount").As("total")).GroupBy("user").
+	//    Having(NewCondition().And("total__gt", 100)).Aggregate(&res)
+	Having(cond *Condition) QuerySeter
+	// query annotated aggregations grouped by GroupBy fields,
+	// container can be *[]Params, *[]ParamsList or pointer to struct slice.
+	// exprs are grouped fields to select, default is GroupBy fields,
+	// related fields are supported, e.g. "profile__age".
+	Aggregate(container interface{}, exprs ...string) (int64, error)
+	// keyset pagination, read rows after the cursor in current ordering.
+	// the ordering is completed with pk, Count, Update and Delete ignore the cursor.
+	// order fields must be not null model columns, NULL cannot be compared by keyset.
+	// for example:
+	//  qs := o.QueryTable("user").OrderBy("-created_at").Limit(20)
+	//  qs.After(token).All(&users)
+	//  next, err := qs.NextCursor(users[len(users)-1])
+	After(cursor string) QuerySeter
+	// keyset pagination, read rows before the cursor in current ordering.
+	Before(cursor string) QuerySeter
+	// return the opaque cursor of md to read the rows after md.
+	NextCursor(md interface{}) (string, error)
+	// return the opaque cursor of md to read the rows before md.
+	PrevCursor(md interface{}) (string, error)
 	// return QuerySeter execution result number
 	// for example:
 	//	num, err = qs.Filter("profile__age__gt", 28).Count()
@@ -232,7 +367,10 @@ type QuerySeter interface {
 	//for example:
 	//	num ,err = qs.Filter("user_name__in", "testing1", "testing2").Delete()
 	// 	//delete two user  who's name is testing1 or testing2
+	// model with soft_delete field is marked as deleted instead of removed.
 	Delete() (int64, error)
+	// delete from table, soft_delete field is ignored and soft deleted rows are deleted too.
+	ForceDelete() (int64, error)
 	// return a insert queryer.
 	// it can be used in times.
 	// example:
@@ -299,7 +437,20 @@ type QuerySeter interface {
 	//	search string on username == 'slene'
 	//	qs.Search("abcd", "name", "email")
 	//	sql : where (name like '%abcd%' or email like '%abcd%')
+	// use name__search lookup for full-text search on mysql and postgres.
 	Search(string, ...string) QuerySeter
+	// return sub query selecting one field, used as value of Filter, Exclude and Condition.
+	// for example:
+	//	adults := o.QueryTable("user_profile").Filter("age__gte", 18).ValuesExpr("id")
+	//	qs.Filter("profile__in", adults)
+	//	// WHERE T0.`profile_id` IN (SELECT T0.`id` FROM `user_profile` T0 WHERE T0.`age` >= ?)
+	// use orm.Col to compare with another column of model:
+	//	qs.Filter("updated__gt", orm.Col("created"))
+	ValuesExpr(expr string) *SubQuery
+	// set context to QuerySeter, the query is aborted when ctx is canceled.
+	// for example:
+	//	qs.WithContext(ctx).Filter("name", "slene").All(&users)
+	WithContext(ctx context.Context) QuerySeter
 }
 
 // QueryM2Mer model to model query struct
@@ -359,6 +510,12 @@ type RawSeter interface {
 	//	num, err = dORM.Raw(query).QueryRows(&ids,&names) // ids=>{1,2},names=>{"nobody","slene"}
 	QueryRows(containers ...interface{}) (int64, error)
 	SetArgs(...interface{}) RawSeter
+	// cache results of QueryRow, QueryRows, Values and RowsTo in QueryCache for ttl,
+	// keyed by the sql and args. tables are the tables of query, cached results are
+	// invalidated when they are written by orm. queries in transaction are not cached.
+	// for example:
+	//	rs.Cache(time.Minute, "user", "profile").QueryRows(&users)
+	Cache(ttl time.Duration, tables ...string) RawSeter
 	// query data to []map[string]interface
 	// see QuerySeter's Values
 	Values(container *[]Params, cols ...string) (int64, error)
@@ -396,6 +553,10 @@ type RawSeter interface {
 	// 	pre, err := dORM.Raw("INSERT INTO tag (name) VALUES (?)").Prepare()
 	// 	r, err := pre.Exec("name1") // INSERT INTO tag (name) VALUES (`name1`)
 	Prepare() (RawPreparer, error)
+	// set context to RawSeter, the query is aborted when ctx is canceled.
+	// for example:
+	//	dORM.Raw("SELECT * FROM user").WithContext(ctx).QueryRows(&users)
+	WithContext(ctx context.Context) RawSeter
 }
 
 // stmtQuerier statement querier
@@ -447,23 +608,29 @@ type dbBaser interface {
 	Insert(dbQuerier, *modelInfo, reflect.Value, *time.Location) (int64, error)
 	InsertOrUpdate(dbQuerier, *modelInfo, reflect.Value, *alias, ...string) (int64, error)
 	InsertMulti(dbQuerier, *modelInfo, reflect.Value, int, *time.Location) (int64, error)
+	InsertOrUpdateMulti(dbQuerier, *modelInfo, reflect.Value, int, []string, []string, *time.Location) (int64, error)
 	InsertValue(dbQuerier, *modelInfo, bool, []string, []interface{}) (int64, error)
 	InsertStmt(stmtQuerier, *modelInfo, reflect.Value, *time.Location) (int64, error)
 	Update(dbQuerier, *modelInfo, reflect.Value, *time.Location, []string) (int64, error)
 	Delete(dbQuerier, *modelInfo, reflect.Value, *time.Location, []string) (int64, error)
+	SoftDelete(dbQuerier, *modelInfo, reflect.Value, *time.Location, []string) (int64, error)
 	ReadBatch(dbQuerier, *querySet, *modelInfo, *Condition, interface{}, *time.Location, []string) (int64, error)
+	ReadIter(dbQuerier, *querySet, *modelInfo, *Condition, *time.Location, func(interface{}) error) (int64, error)
 	SupportUpdateJoin() bool
+	SupportSavepoint() bool
 	UpdateBatch(dbQuerier, *querySet, *modelInfo, *Condition, Params, *time.Location) (int64, error)
 	DeleteBatch(dbQuerier, *querySet, *modelInfo, *Condition, *time.Location) (int64, error)
 	Count(dbQuerier, *querySet, *modelInfo, *Condition, *time.Location) (int64, error)
 	OperatorSQL(string) string
 	GenerateOperatorSQL(*modelInfo, *fieldInfo, string, []interface{}, *time.Location) (string, []interface{})
 	GenerateOperatorLeftCol(*fieldInfo, string, *string)
+	GenerateJSONPathCol(string, []string) (string, []interface{})
 	PrepareInsert(dbQuerier, *modelInfo) (stmtQuerier, string, error)
 	ReadValues(dbQuerier, *querySet, *modelInfo, *Condition, []string, interface{}, *time.Location) (int64, error)
 	RowsTo(dbQuerier, *querySet, *modelInfo, *Condition, interface{}, string, string, *time.Location) (int64, error)
 	MaxLimit() uint64
 	TableQuote() string
+	UpsertSQL([]string, []string) (string, error)
 	ReplaceMarks(*string)
 	HasReturningID(*modelInfo, *string) bool
 	TimeFromDB(*time.Time, *time.Location)
@@ -471,6 +638,7 @@ type dbBaser interface {
 	DbTypes() map[string]string
 	GetTables(dbQuerier) (map[string]bool, error)
 	GetColumns(dbQuerier, string) (map[string][3]string, error)
+	GetColumnsInfo(dbQuerier, string) (map[string]dbColumn, error)
 	ShowTablesQuery() string
 	ShowColumnsQuery(string) string
 	IndexExists(dbQuerier, string, string) bool
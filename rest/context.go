@@ -131,6 +131,11 @@ func (c *Context) ID() int64 {
 	return int64(id)
 }
 
+// IDString return id parameters from route as string, such as uuid id.
+func (c *Context) IDString() string {
+	return c.Param("id")
+}
+
 // ParamNames returns path parameter names.
 func (c *Context) ParamNames() []string {
 	return c.pnames
@@ -217,6 +217,12 @@ func TestContextPathParam(t *testing.T) {
 
 	// Param
 	assert.Equal(t, "501", c.Param("fid"))
+
+	// ID
+	c.SetParamNames("id")
+	c.SetParamValues("0190a3b2-7c1d-7e4f-8a9b-0c1d2e3f4a5b")
+	assert.Equal(t, "0190a3b2-7c1d-7e4f-8a9b-0c1d2e3f4a5b", c.IDString())
+	assert.Equal(t, int64(0), c.ID())
 }
 
 func TestContextQueryParam(t *testing.T) {
@@ -0,0 +1,33 @@
+// Copyright 2019 Kora ID. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package mw
+
+import (
+	"fmt"
+
+	"github.com/raryanda/go/orm"
+	"github.com/raryanda/go/rest"
+	"go.uber.org/zap"
+)
+
+// QueryLogger returns a middleware that logs the database queries of HTTP requests.
+func QueryLogger() rest.MiddlewareFunc {
+	return func(n rest.HandlerFunc) rest.HandlerFunc {
+		return func(c *rest.Context) error {
+			req := c.Request()
+			ctx, stats := orm.WithQueryStats(req.Context())
+			c.SetRequest(req.WithContext(ctx))
+
+			err := n(c)
+			if stats.Count() > 0 {
+				c.Logger().Info(fmt.Sprintf("%s/QUERY", req.Method),
+					zap.String("path", req.URL.Path),
+					zap.String("queries", fmt.Sprintf("%d queries, %1.1fms", stats.Count(), float64(stats.Duration()/1e5)/10.0)),
+				)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raryanda/go/orm"
+	"github.com/raryanda/go/rest"
+	"github.com/stretchr/testify/assert"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestQueryLogger(t *testing.T) {
+	e := rest.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	var stats *orm.QueryStats
+	handler := func(c *rest.Context) error {
+		stats = orm.QueryStatsFromContext(c.Request().Context())
+		return c.String(http.StatusOK, "test")
+	}
+
+	h := QueryLogger()(handler)
+	assert.NoError(t, h(c))
+	assert.NotNil(t, stats)
+	assert.Equal(t, int64(0), stats.Count())
+}
+
+func TestQueryLoggerCount(t *testing.T) {
+	assert.NoError(t, orm.RegisterDataBase("default", "sqlite3", "file:query_logger_test?mode=memory"))
+
+	e := rest.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	var stats *orm.QueryStats
+	handler := func(c *rest.Context) error {
+		ctx := c.Request().Context()
+		stats = orm.QueryStatsFromContext(ctx)
+
+		// queries of orm not bound to the request context are not counted
+		o := orm.NewOrm()
+		if _, err := o.Raw("SELECT 1").Exec(); err != nil {
+			return err
+		}
+		if _, err := o.WithContext(ctx).Raw("SELECT 1").Exec(); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, "test")
+	}
+
+	h := QueryLogger()(handler)
+	assert.NoError(t, h(c))
+	assert.NotNil(t, stats)
+	assert.Equal(t, int64(1), stats.Count())
+	assert.True(t, stats.Duration() > 0)
+}
@@ -7,17 +7,20 @@ package rest
 import (
 	"net/http"
 
+	"github.com/raryanda/go/orm"
 	"github.com/raryanda/go/validation"
 )
 
 // ResponseFormat is standart response formater of the applicatin.
 type ResponseFormat struct {
-	Code    int               `json:"-"`
-	Status  string            `json:"status,omitempty"`
-	Message interface{}       `json:"message,omitempty"`
-	Data    interface{}       `json:"data,omitempty"`
-	Total   int64             `json:"total,omitempty"`
-	Errors  map[string]string `json:"errors,omitempty"`
+	Code       int               `json:"-"`
+	Status     string            `json:"status,omitempty"`
+	Message    interface{}       `json:"message,omitempty"`
+	Data       interface{}       `json:"data,omitempty"`
+	Total      int64             `json:"total,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+	Errors     map[string]string `json:"errors,omitempty"`
 }
 
 // SetError set an error into response formater.
@@ -26,6 +29,8 @@ func (r *ResponseFormat) SetError(err error) {
 	r.Status = HTTPResponseFailed
 	r.Data = nil
 	r.Total = 0
+	r.NextCursor = ""
+	r.PrevCursor = ""
 
 	// Check error based on type
 	if he, ok := err.(*HTTPError); ok {
@@ -37,6 +42,10 @@ func (r *ResponseFormat) SetError(err error) {
 		// status 422 and returning all failure messages as errors.
 		r.Code = http.StatusUnprocessableEntity
 		r.Errors = o.GetErrors()
+	} else if err == orm.ErrStaleObject {
+		// Error cause of concurrent modification should return
+		// status 409 so client can reload and retry.
+		r.Code = http.StatusConflict
 	}
 
 	r.Message = http.StatusText(r.Code)
@@ -48,4 +57,6 @@ func (r *ResponseFormat) reset() {
 	r.Errors = nil
 	r.Message = nil
 	r.Total = 0
+	r.NextCursor = ""
+	r.PrevCursor = ""
 }
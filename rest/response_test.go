@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/raryanda/go/orm"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +23,13 @@ func TestResponse(t *testing.T) {
 	res.Write([]byte("test"))
 	assert.Equal(t, "rest", rec.Header().Get(HeaderServer))
 }
+
+func TestResponseFormatSetError(t *testing.T) {
+	r := new(ResponseFormat)
+	r.SetError(orm.ErrStaleObject)
+	assert.Equal(t, http.StatusConflict, r.Code)
+	assert.Equal(t, http.StatusText(http.StatusConflict), r.Message)
+
+	r.SetError(&HTTPError{Code: http.StatusNotFound})
+	assert.Equal(t, http.StatusNotFound, r.Code)
+}